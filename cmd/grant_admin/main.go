@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/user"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+
+	"github.com/joho/godotenv"
+)
+
+// Grants the admin role to an existing account. Intended for bootstrapping
+// the first admin; once one exists, roles are managed via PUT /user/:id/role.
+func main() {
+	email := flag.String("email", "", "email of the account to promote")
+	reason := flag.String("reason", "", "reason recorded in the role audit log")
+	force := flag.Bool("force", false, "grant even if an admin already exists")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Не удалось загрузить .env файл: %v", err)
+	}
+
+	db, err := repository.InitDb()
+	if err != nil {
+		log.Fatalf("Ошибка подключения к базе данных: %v", err)
+	}
+	if err := repository.MigrateDatabase(db); err != nil {
+		log.Fatalf("Ошибка миграции: %v", err)
+	}
+
+	ctx := context.Background()
+	m := repository.NewModels(db)
+
+	admins, err := m.Users.CountByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		log.Fatalf("Failed to count admins: %v", err)
+	}
+	if admins > 0 && !*force {
+		log.Fatalf("%d admin(s) already exist; use PUT /user/:id/role or pass -force", admins)
+	}
+
+	u, err := m.Users.GetByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("User %s not found: %v", *email, err)
+	}
+
+	note := "bootstrap via cmd/grant_admin"
+	if osUser, err := user.Current(); err == nil {
+		note += " by " + osUser.Username
+	}
+	if *reason != "" {
+		note += ": " + *reason
+	}
+
+	if _, err := m.Users.SetRole(ctx, u.ID, models.RoleAdmin, nil, note); err != nil {
+		log.Fatalf("Failed to grant admin: %v", err)
+	}
+
+	log.Printf("Granted admin to %s (%s); the user must sign in again to pick up the new role", u.Email, u.ID)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+
+	"modern-social-media/internal/auth"
+)
+
+// Generates a PEM signing key for JWT_PRIVATE_KEY_FILE. To rotate: generate a
+// new key, point JWT_PRIVATE_KEY_FILE at it and append the previous file to
+// JWT_VERIFY_KEY_FILES until the old refresh tokens have expired.
+func main() {
+	alg := flag.String("alg", "ed25519", "key type: ed25519 or rsa")
+	out := flag.String("out", "jwt_signing_key.pem", "output file")
+	flag.Parse()
+
+	var priv crypto.Signer
+	var err error
+	switch *alg {
+	case "ed25519":
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		priv, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		log.Fatalf("unsupported key type %q", *alg)
+	}
+	if err != nil {
+		log.Fatalf("Key generation failed: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		log.Fatalf("Failed to encode key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	key, err := auth.ParseKeyPEM(data)
+	if err != nil {
+		log.Fatalf("Generated key is not usable: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s key to %s (kid %s)", key.Algorithm, *out, key.ID)
+}
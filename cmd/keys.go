@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"modern-social-media/internal/auth"
+	"modern-social-media/internal/env"
+)
+
+// loadKeys builds the JWT key set from the environment:
+//   - JWT_PRIVATE_KEY_FILE: PEM private key (Ed25519 or RSA) used to sign new tokens
+//   - JWT_VERIFY_KEY_FILES: comma-separated PEM keys of previous signing keys that
+//     should still be accepted until their tokens expire
+//   - JWT_SECRET: legacy HS256 secret, accepted for verification only
+//   - JWT_EPHEMERAL_KEY: local development only; sign with a throwaway key when
+//     JWT_PRIVATE_KEY_FILE is unset instead of refusing to start
+func loadKeys() *auth.KeyManager {
+	var active *auth.SigningKey
+	var err error
+	if path := env.GetEnvString("JWT_PRIVATE_KEY_FILE", ""); path != "" {
+		active, err = auth.LoadKeyFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing key: %v", err)
+		}
+	} else {
+		if !env.GetEnvBool("JWT_EPHEMERAL_KEY", false) {
+			log.Fatal("JWT_PRIVATE_KEY_FILE is not set; generate one with `go run ./cmd/jwt_keygen` or set JWT_EPHEMERAL_KEY=true for local development")
+		}
+		active, err = auth.GenerateEd25519Key()
+		if err != nil {
+			log.Fatalf("Failed to generate JWT signing key: %v", err)
+		}
+		log.Println("JWT_PRIVATE_KEY_FILE is not set, using an ephemeral Ed25519 key; tokens will not survive a restart")
+	}
+
+	var verifyOnly []*auth.SigningKey
+	for _, path := range strings.Split(env.GetEnvString("JWT_VERIFY_KEY_FILES", ""), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		key, err := auth.LoadKeyFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load JWT verification key: %v", err)
+		}
+		verifyOnly = append(verifyOnly, key)
+	}
+
+	var legacySecret []byte
+	if secret := env.GetEnvString("JWT_SECRET", ""); secret != "" {
+		legacySecret = []byte(secret)
+	}
+
+	keys, err := auth.NewKeyManager(active, verifyOnly, legacySecret)
+	if err != nil {
+		log.Fatalf("Failed to initialise JWT keys: %v", err)
+	}
+	log.Printf("Signing JWTs with %s key %s (%d verification keys)", active.Algorithm, active.ID, len(keys.JWKS().Keys))
+	return keys
+}
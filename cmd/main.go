@@ -13,6 +13,7 @@ import (
 	"log"
 	"time"
 
+	"modern-social-media/internal/auth"
 	"modern-social-media/internal/env"
 	"modern-social-media/internal/repository"
 	"modern-social-media/internal/services"
@@ -22,11 +23,12 @@ import (
 
 type application struct {
 	port            int
-	jwtSecret       string
-	adminToken      string
+	keys            *auth.KeyManager
 	models          repository.Models
 	mailer          services.EmailSender
+	feedRanker      *services.FeedRanker
 	email2FAEnabled bool
+	trustedProxies  []string
 }
 
 func main() {
@@ -71,6 +73,59 @@ func main() {
 			}
 		}
 	}()
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(6 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := models.RefreshTokens.DeleteExpired(ctx); err != nil {
+				log.Printf("Refresh token cleanup failed: %v", err)
+			}
+			if err := models.Sessions.DeleteExpired(ctx); err != nil {
+				log.Printf("Session cleanup failed: %v", err)
+			}
+			if err := models.LoginAttempts.DeleteStale(ctx, time.Now().Add(-24*time.Hour)); err != nil {
+				log.Printf("Login attempt cleanup failed: %v", err)
+			}
+		}
+	}()
+	accountPurge := services.NewAccountPurgeService(models.Users)
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := accountPurge.PurgeDueAccounts(ctx); err != nil {
+				log.Printf("Account purge failed: %v", err)
+			}
+		}
+	}()
+	trashPurge := services.NewTrashPurgeService(models.Trash)
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := trashPurge.PurgeExpired(ctx); err != nil {
+				log.Printf("Trash purge failed: %v", err)
+			}
+		}
+	}()
+	trends := loadHashtagTrends(models.Hashtags)
+	go func() {
+		ctx := context.Background()
+		if err := trends.RefreshTrending(ctx); err != nil {
+			log.Printf("Initial trending refresh failed: %v", err)
+		}
+
+		ticker := time.NewTicker(trends.Refresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := trends.RefreshTrending(ctx); err != nil {
+				log.Printf("Trending refresh failed: %v", err)
+			}
+		}
+	}()
 	mailer := &services.SMTPSender{
 		Host:     env.GetEnvString("SMTP_HOST", "localhost"),
 		Port:     env.GetEnvInt("SMTP_PORT", 587),
@@ -78,13 +133,28 @@ func main() {
 		Password: env.GetEnvString("SMTP_PASSWORD", ""),
 		From:     env.GetEnvString("SMTP_FROM", "noreply@example.com"),
 	}
+	dataExports := services.NewDataExportService(*models, mailer, env.GetEnvString("PUBLIC_BASE_URL", "http://localhost:8080"))
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := dataExports.ProcessPending(ctx); err != nil {
+				log.Printf("Data export failed: %v", err)
+			}
+			if err := dataExports.CleanupExpired(ctx); err != nil {
+				log.Printf("Data export cleanup failed: %v", err)
+			}
+		}
+	}()
 	app := &application{
 		port:            env.GetEnvInt("PORT", 8080),
-		jwtSecret:       env.GetEnvString("JWT_SECRET", ""),
-		adminToken:      env.GetEnvString("ADMIN_TOKEN", ""),
+		keys:            loadKeys(),
 		models:          *models,
 		mailer:          mailer,
+		feedRanker:      loadFeedRanker(models.Posts),
 		email2FAEnabled: env.GetEnvBool("EMAIL_2FA_ENABLED", true),
+		trustedProxies:  loadTrustedProxies(),
 	}
 
 	if err := app.serve(); err != nil {
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	"modern-social-media/internal/env"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
+)
+
+// loadFeedRanker configures the "For You" feed from the environment. Every
+// FEED_WEIGHT_* variable falls back to services.DefaultRankingWeights.
+func loadFeedRanker(posts repository.PostRepository) *services.FeedRanker {
+	w := services.DefaultRankingWeights()
+	w.Like = env.GetEnvFloat("FEED_WEIGHT_LIKE", w.Like)
+	w.Comment = env.GetEnvFloat("FEED_WEIGHT_COMMENT", w.Comment)
+	w.Follow = env.GetEnvFloat("FEED_WEIGHT_FOLLOW", w.Follow)
+	w.SocialProof = env.GetEnvFloat("FEED_WEIGHT_SOCIAL_PROOF", w.SocialProof)
+	w.AuthorLike = env.GetEnvFloat("FEED_WEIGHT_AUTHOR_LIKE", w.AuthorLike)
+	w.HalfLife = time.Duration(env.GetEnvFloat("FEED_HALF_LIFE_HOURS", w.HalfLife.Hours()) * float64(time.Hour))
+
+	return &services.FeedRanker{
+		Posts:           posts,
+		Scorer:          services.EngagementScorer{Weights: w},
+		CandidateWindow: time.Duration(env.GetEnvInt("FEED_CANDIDATE_WINDOW_HOURS", 72)) * time.Hour,
+		CandidateLimit:  env.GetEnvInt("FEED_CANDIDATE_LIMIT", 500),
+		Debug:           env.GetEnvBool("FEED_DEBUG", false),
+	}
+}
+
+// loadHashtagTrends configures trending tags from the environment. Baseline
+// is kept longer than Window so the expected rate is always defined.
+func loadHashtagTrends(tags repository.HashtagRepository) *services.HashtagTrends {
+	window := time.Duration(env.GetEnvInt("TRENDING_WINDOW_HOURS", 6)) * time.Hour
+	if window <= 0 {
+		window = 6 * time.Hour
+	}
+	baseline := time.Duration(env.GetEnvInt("TRENDING_BASELINE_HOURS", 7*24)) * time.Hour
+	if baseline <= window {
+		baseline = 2 * window
+	}
+	refresh := time.Duration(env.GetEnvInt("TRENDING_REFRESH_MINUTES", 10)) * time.Minute
+	if refresh <= 0 {
+		refresh = 10 * time.Minute
+	}
+
+	return &services.HashtagTrends{
+		Repo: tags,
+		Params: repository.TrendingParams{
+			Window:     window,
+			Baseline:   baseline,
+			MinAuthors: env.GetEnvInt("TRENDING_MIN_AUTHORS", 3),
+			Limit:      env.GetEnvInt("TRENDING_LIMIT", 50),
+		},
+		Refresh: refresh,
+	}
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"log"
 	introutes "modern-social-media/internal/routes"
 	"net/http"
+	"strings"
 	"time"
 
+	"modern-social-media/internal/env"
 	"modern-social-media/internal/handlers"
+	"modern-social-media/internal/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,6 +19,11 @@ import (
 
 func (app *application) routes() http.Handler {
 	g := gin.New()
+	// ClientIP feeds the per-IP attempt limiter, so X-Forwarded-For is only
+	// honoured when it comes from a configured proxy.
+	if err := g.SetTrustedProxies(app.trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	g.Use(gin.Logger(), gin.Recovery())
 
 	g.Use(cors.New(cors.Config{
@@ -32,6 +41,8 @@ func (app *application) routes() http.Handler {
 		c.File("./openapi.json")
 	})
 
+	g.GET("/.well-known/jwks.json", handlers.JWKS(app.keys))
+
 	g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler,
 		ginSwagger.URL("/openapi.json")))
 
@@ -40,8 +51,10 @@ func (app *application) routes() http.Handler {
 	deps := introutes.Deps{
 		Models:          app.models,
 		Mailer:          app.mailer,
-		JWTSecret:       app.jwtSecret,
-		AdminToken:      app.adminToken,
+		Keys:            app.keys,
+		FeedRanker:      app.feedRanker,
+		Mentions:        services.NewMentionService(app.models),
+		Reposts:         services.NewRepostService(app.models),
 		Email2FAEnabled: app.email2FAEnabled,
 	}
 	introutes.RegisterUserRoutes(v1, deps)
@@ -52,9 +65,26 @@ func (app *application) routes() http.Handler {
 	introutes.RegisterFollowRoutes(v1, deps)
 	introutes.RegisterSkillRoutes(v1, deps)
 	introutes.RegisterNotificationRoutes(v1, deps)
+	introutes.RegisterDataExportRoutes(v1, deps)
+	introutes.RegisterTrashRoutes(v1, deps)
+	introutes.RegisterHashtagRoutes(v1, deps)
+	introutes.RegisterBookmarkRoutes(v1, deps)
 
 	hub := handlers.NewHub()
 	introutes.RegisterChatRoutes(v1, deps, hub)
 
 	return g
 }
+
+// loadTrustedProxies reads TRUSTED_PROXIES, a comma-separated list of proxy
+// IPs or CIDRs allowed to set X-Forwarded-For. Empty trusts no proxy and uses
+// the connection's address.
+func loadTrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(env.GetEnvString("TRUSTED_PROXIES", ""), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
@@ -67,7 +67,7 @@ func main() {
 	log.Println("Clearing existing posts...")
 	if err := db.Exec("TRUNCATE TABLE posts CASCADE").Error; err != nil {
 		log.Println("Truncate failed, trying Delete:", err)
-		if err := db.Where("1 = 1").Delete(&models.Post{}).Error; err != nil {
+		if err := db.Unscoped().Where("1 = 1").Delete(&models.Post{}).Error; err != nil {
 			log.Printf("Failed to clear posts: %v", err)
 		}
 	}
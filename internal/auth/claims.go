@@ -7,21 +7,29 @@ import (
 )
 
 type AccessClaims struct {
+	UserID      string   `json:"sub"`
+	SessionID   string   `json:"sid,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
+	Type        string   `json:"type"`
+	jwt.RegisteredClaims
+}
+
+type RefreshClaims struct {
 	UserID   string `json:"sub"`
-	Email    string `json:"email,omitempty"`
-	Username string `json:"username,omitempty"`
-	IsAdmin  bool   `json:"is_admin,omitempty"`
+	FamilyID string `json:"fid"`
 	Type     string `json:"type"`
 	jwt.RegisteredClaims
 }
 
-type RefreshClaims struct {
+type MFAClaims struct {
 	UserID string `json:"sub"`
 	Type   string `json:"type"`
 	jwt.RegisteredClaims
 }
 
-
 func (c AccessClaims) Validate() error {
 	if c.Type != "access" {
 		return errors.New("invalid token type: expected access")
@@ -39,5 +47,18 @@ func (c RefreshClaims) Validate() error {
 	if c.UserID == "" {
 		return errors.New("missing subject (user ID)")
 	}
+	if c.ID == "" || c.FamilyID == "" {
+		return errors.New("missing token ID or family")
+	}
+	return nil
+}
+
+func (c MFAClaims) Validate() error {
+	if c.Type != "mfa" {
+		return errors.New("invalid token type: expected mfa")
+	}
+	if c.UserID == "" {
+		return errors.New("missing subject (user ID)")
+	}
 	return nil
 }
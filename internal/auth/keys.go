@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// SigningKey is an asymmetric JWT key. Private is nil for keys that are only
+// kept around to verify tokens issued before a rotation.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeyManager signs every token with the active key and verifies tokens
+// against any key it knows by "kid". Tokens without a kid are accepted only
+// when a legacy HS256 secret is configured, so sessions issued before the
+// switch to asymmetric keys survive until they expire.
+type KeyManager struct {
+	active       *SigningKey
+	keys         map[string]*SigningKey
+	legacySecret []byte
+}
+
+func NewKeyManager(active *SigningKey, verifyOnly []*SigningKey, legacySecret []byte) (*KeyManager, error) {
+	if active == nil || active.Private == nil {
+		return nil, errors.New("active signing key must include a private key")
+	}
+	m := &KeyManager{
+		active:       active,
+		keys:         map[string]*SigningKey{active.ID: active},
+		legacySecret: legacySecret,
+	}
+	for _, k := range verifyOnly {
+		if _, exists := m.keys[k.ID]; exists {
+			continue
+		}
+		m.keys[k.ID] = k
+	}
+	return m, nil
+}
+
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(signingMethod(m.active.Algorithm), claims)
+	token.Header["kid"] = m.active.ID
+	return token.SignedString(m.active.Private)
+}
+
+func (m *KeyManager) Parse(tokenStr string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenStr, claims, m.keyFunc)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func (m *KeyManager) ParseAccess(tokenStr string) (*AccessClaims, error) {
+	var claims AccessClaims
+	if err := m.Parse(tokenStr, &claims); err != nil {
+		return nil, errors.New("invalid token")
+	}
+	if err := claims.Validate(); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (m *KeyManager) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		if len(m.legacySecret) == 0 || t.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("missing key id")
+		}
+		return m.legacySecret, nil
+	}
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	if alg == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+func GenerateEd25519Key() (*SigningKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(priv, pub)
+}
+
+func LoadKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKeyPEM accepts PKCS#8 / PKCS#1 private keys and PKIX public keys.
+func ParseKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return newSigningKey(signer, signer.Public())
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newSigningKey(parsed, parsed.Public())
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newSigningKey(nil, parsed)
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+func newSigningKey(priv crypto.Signer, pub crypto.PublicKey) (*SigningKey, error) {
+	key := &SigningKey{Private: priv, Public: pub}
+	switch p := pub.(type) {
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	case *rsa.PublicKey:
+		if p.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Algorithm = AlgRS256
+	default:
+		return nil, errors.New("only Ed25519 and RSA keys are supported")
+	}
+	key.ID = thumbprint(key.JWK())
+	return key, nil
+}
+
+// JWK is the public half of a key as published in the JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch p := k.Public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(p)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(p.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.E)).Bytes())
+	}
+	return jwk
+}
+
+// JWKS lists every asymmetric key that may still appear in a valid token.
+// The legacy HS256 secret is never published.
+func (m *KeyManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{m.active.JWK()}}
+	for id, k := range m.keys {
+		if id == m.active.ID {
+			continue
+		}
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	return set
+}
+
+// thumbprint derives the kid from the key itself (RFC 7638), so the same PEM
+// file always yields the same kid across restarts and replicas.
+func thumbprint(jwk JWK) string {
+	var members any
+	switch jwk.Kty {
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	}
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeleteAccountWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		at, err := svc.RequestAccountDeletion(c.Request.Context(), c.GetString("userID"), req.Password, clientMeta(c))
+		if err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "invalid_credentials":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			case "too_many_attempts":
+				RespondTooManyAttempts(c, err)
+			case "last_admin":
+				c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last admin"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+			}
+			return
+		}
+		clearRefreshCookie(c)
+		c.JSON(http.StatusAccepted, gin.H{"status": "deletion_scheduled", "deletion_scheduled_at": at})
+	}
+}
+
+func RestoreAccountWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		if err := svc.RestoreAccount(c.Request.Context(), req.Email, req.Password, clientMeta(c)); err != nil {
+			switch err.Error() {
+			case "invalid_credentials":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			case "not_pending_deletion":
+				c.JSON(http.StatusConflict, gin.H{"error": "Account is not scheduled for deletion"})
+			case "too_many_attempts":
+				RespondTooManyAttempts(c, err)
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "restored"})
+	}
+}
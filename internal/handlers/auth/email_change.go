@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RequestEmailChangeWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		err := svc.RequestEmailChange(c.Request.Context(), c.GetString("userID"), req.Email, req.Password, clientMeta(c))
+		if err != nil {
+			switch err.Error() {
+			case "invalid_email":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "invalid_credentials":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			case "same_email":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "New email matches the current one"})
+			case "email_in_use":
+				c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+			case "too_many_attempts":
+				RespondTooManyAttempts(c, err)
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request email change"})
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"status": "confirmation_sent"})
+	}
+}
+
+func ConfirmEmailChangeWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		u, err := svc.ConfirmEmailChange(c.Request.Context(), c.GetString("userID"), req.Code, clientMeta(c))
+		if err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "invalid_code":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired code"})
+			case "email_in_use":
+				c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+			case "too_many_attempts", "too_many_code_attempts":
+				RespondTooManyAttempts(c, err)
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"email": u.Email})
+	}
+}
@@ -2,12 +2,14 @@ package auth
 
 import (
 	"errors"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
-	"modern-social-media/internal/auth"
+	"modern-social-media/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func setRefreshCookie(c *gin.Context, token string) {
@@ -22,32 +24,31 @@ func setRefreshCookie(c *gin.Context, token string) {
 	)
 }
 
-func parseAccessSubject(jwtSecret string, authz string) (string, error) {
-	if len(authz) < 8 || authz[:7] != "Bearer " {
-		return "", errors.New("missing bearer token")
-	}
-
-	tokenStr := authz[7:]
-	var claims auth.AccessClaims
-
-	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
-
-	if err != nil {
-		return "", errors.New("invalid token")
+func clientMeta(c *gin.Context) services.ClientMeta {
+	ua := c.Request.UserAgent()
+	if len(ua) > 255 {
+		ua = ua[:255]
 	}
+	return services.ClientMeta{UserAgent: ua, IP: c.ClientIP()}
+}
 
-	if !token.Valid {
-		return "", errors.New("invalid token")
+// RespondTooManyAttempts answers 429 for a limiter lockout or a code burned
+// by too many wrong guesses. A burned code can be replaced at once, so it
+// gets the minimum retry_after.
+func RespondTooManyAttempts(c *gin.Context, err error) {
+	retryAfter := 1
+	var rl *services.RateLimitError
+	if errors.As(err, &rl) {
+		retryAfter = int(math.Ceil(rl.RetryAfter.Seconds()))
 	}
-
-	if err := claims.Validate(); err != nil {
-		return "", errors.New("invalid token")
+	msg := "Too many attempts"
+	if err.Error() == "too_many_code_attempts" {
+		msg = "Too many attempts for this code, request a new one"
 	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": msg, "retry_after": retryAfter})
+}
 
-	return claims.UserID, nil
+func clearRefreshCookie(c *gin.Context) {
+	c.SetCookie("refreshToken", "", -1, "/", "", true, true)
 }
@@ -18,17 +18,31 @@ func LoginWithService(svc services.AuthService) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
-		user, tokens, err := svc.Login(c.Request.Context(), req.Email, req.Password)
+		user, tokens, err := svc.Login(c.Request.Context(), req.Email, req.Password, clientMeta(c))
 		if err != nil {
 			switch err.Error() {
 			case "invalid_credentials":
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 				return
+			case "too_many_attempts":
+				RespondTooManyAttempts(c, err)
+				return
 			case "email_not_verified":
 				c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified", "action": "verify_email"})
 				return
+			case "account_pending_deletion":
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account is scheduled for deletion", "action": "restore_account", "deletion_scheduled_at": user.DeletionScheduledAt})
+				return
+			case "account_disabled":
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+				return
 			case "2fa_required":
-				c.JSON(http.StatusOK, gin.H{"status": "2fa_required"})
+				challenge, err := svc.Issue2FAChallenge(user)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"status": "2fa_required", "methods": svc.TwoFactorMethods(user), "challenge": challenge})
 				return
 			default:
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
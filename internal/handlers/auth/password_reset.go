@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ForgotPasswordWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		svc.ForgotPassword(c.Request.Context(), req.Email)
+		c.JSON(http.StatusOK, gin.H{"status": "sent"})
+	}
+}
+
+func ResetPasswordWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email    string `json:"email"`
+			Code     string `json:"code"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		if err := svc.ResetPassword(c.Request.Context(), req.Email, req.Code, req.Password, clientMeta(c)); err != nil {
+			switch err.Error() {
+			case "weak_password":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 8 characters"})
+			case "invalid_code":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+			case "too_many_attempts", "too_many_code_attempts":
+				RespondTooManyAttempts(c, err)
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			}
+			return
+		}
+		clearRefreshCookie(c)
+		c.JSON(http.StatusOK, gin.H{"status": "password_reset"})
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegenerateRecoveryCodesWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		codes, err := svc.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("userID"), req.Password)
+		if err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "invalid_credentials":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			case "2fa_not_enabled":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "2fa not enabled"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+func RecoveryCodesStatusWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		remaining, err := svc.RecoveryCodesRemaining(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count recovery codes"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"remaining": remaining})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionResponse struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	Current    bool   `json:"current"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+func ListSessionsWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		sessions, err := svc.ListSessions(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+		current := c.GetString("sessionID")
+		response := make([]sessionResponse, len(sessions))
+		for i, s := range sessions {
+			response[i] = sessionResponse{
+				ID:         s.ID,
+				UserAgent:  s.UserAgent,
+				IP:         s.IP,
+				Current:    s.ID == current,
+				CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+				LastUsedAt: s.LastUsedAt.Format(time.RFC3339),
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"sessions": response})
+	}
+}
+
+func RevokeSessionWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		sessionID := c.Param("id")
+		if err := svc.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+			if err.Error() == "not_found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		if sessionID == c.GetString("sessionID") {
+			clearRefreshCookie(c)
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func RevokeOtherSessionsWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if err := svc.RevokeOtherSessions(c.Request.Context(), userID, c.GetString("sessionID")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+	}
+}
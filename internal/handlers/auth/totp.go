@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EnrollTOTPWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, err := svc.EnrollTOTP(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "totp_already_enabled":
+				c.JSON(http.StatusConflict, gin.H{"error": "Authenticator app already enabled"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll authenticator app"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+func ConfirmTOTPWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		codes, err := svc.ConfirmTOTP(c.Request.Context(), c.GetString("userID"), req.Code)
+		if err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "totp_already_enabled":
+				c.JSON(http.StatusConflict, gin.H{"error": "Authenticator app already enabled"})
+			case "totp_not_enrolled":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Authenticator app not enrolled"})
+			case "invalid_2fa_code":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid 2fa code"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm authenticator app"})
+			}
+			return
+		}
+		resp := gin.H{"totp_enabled": true}
+		if codes != nil {
+			resp["recovery_codes"] = codes
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func DisableTOTPWithService(svc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		if err := svc.DisableTOTP(c.Request.Context(), c.GetString("userID"), req.Code); err != nil {
+			switch err.Error() {
+			case "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case "totp_not_enabled":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Authenticator app not enabled"})
+			case "invalid_2fa_code":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid 2fa code"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable authenticator app"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"totp_enabled": false})
+	}
+}
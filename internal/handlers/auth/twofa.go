@@ -1,36 +1,45 @@
 package auth
 
 import (
-	"errors"
 	"net/http"
-	"time"
 
-	"modern-social-media/internal/auth"
 	"modern-social-media/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func VerifyLogin2FAWithService(svc services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Email string `json:"email"`
-			Code  string `json:"code"`
+			Email     string `json:"email"`
+			Code      string `json:"code"`
+			Method    string `json:"method"`
+			Challenge string `json:"challenge"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
-		user, tokens, err := svc.VerifyLogin2FA(c.Request.Context(), req.Email, req.Code)
+		user, tokens, err := svc.VerifyLogin2FA(c.Request.Context(), services.Verify2FAInput{
+			Email:     req.Email,
+			Code:      req.Code,
+			Method:    req.Method,
+			Challenge: req.Challenge,
+		}, clientMeta(c))
 		if err != nil {
 			switch err.Error() {
 			case "invalid_credentials":
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			case "2fa_not_enabled":
 				c.JSON(http.StatusBadRequest, gin.H{"error": "2fa not enabled"})
+			case "2fa_method_not_enabled", "invalid_2fa_method":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "2fa method not available"})
+			case "invalid_challenge":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired 2fa challenge"})
 			case "invalid_2fa_code":
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired 2fa code"})
+			case "too_many_attempts", "too_many_code_attempts":
+				RespondTooManyAttempts(c, err)
 			default:
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign token"})
 			}
@@ -69,7 +78,7 @@ func Request2FACodeWithService(svc services.AuthService) gin.HandlerFunc {
 	}
 }
 
-func Toggle2FAWithService(svc services.AuthService, jwtSecret string) gin.HandlerFunc {
+func Toggle2FAWithService(svc services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			Enable bool `json:"enable"`
@@ -78,31 +87,24 @@ func Toggle2FAWithService(svc services.AuthService, jwtSecret string) gin.Handle
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
-		sub, err := parseAccessSubject(jwtSecret, c.GetHeader("Authorization"))
+		user, codes, err := svc.Toggle2FA(c.Request.Context(), c.GetString("userID"), req.Enable)
 		if err != nil {
-			msg := err.Error()
-			if msg == "missing bearer token" {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			if err.Error() == "not_found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
 			}
-			return
-		}
-		user, err := svc.Users.GetByID(c.Request.Context(), sub)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-			return
-		}
-		user.Is2FAEnabled = req.Enable
-		if err := svc.Users.UpdateUser(c.Request.Context(), user); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"is_2fa_enabled": user.Is2FAEnabled})
+		resp := gin.H{"is_2fa_enabled": user.Is2FAEnabled(), "email_2fa_enabled": user.Email2FAEnabled}
+		if codes != nil {
+			resp["recovery_codes"] = codes
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
-func Refresh(jwtSecret string) gin.HandlerFunc {
+func Refresh(svc services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		cookie, err := c.Cookie("refreshToken")
 		if err != nil || cookie == "" {
@@ -110,48 +112,22 @@ func Refresh(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		var claims auth.RefreshClaims
-		token, err := jwt.ParseWithClaims(cookie, &claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
-			return
-		}
-
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
-			return
-		}
-
-		if err := claims.Validate(); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type"})
-			return
-		}
-
-		// Create new access token
-		now := time.Now()
-		accClaims := auth.AccessClaims{
-			UserID: claims.UserID,
-			Type:   "access",
-			RegisteredClaims: jwt.RegisteredClaims{
-				Subject:   claims.UserID,
-				IssuedAt:  jwt.NewNumericDate(now),
-				ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)),
-			},
-		}
-
-		accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accClaims)
-		signed, err := accessToken.SignedString([]byte(jwtSecret))
+		_, tokens, err := svc.Refresh(c.Request.Context(), cookie, clientMeta(c))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign token"})
+			switch err.Error() {
+			case "invalid_refresh":
+				clearRefreshCookie(c)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			case "refresh_reused":
+				clearRefreshCookie(c)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign token"})
+			}
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"token": signed})
+		setRefreshCookie(c, tokens.Refresh)
+		c.JSON(http.StatusOK, gin.H{"token": tokens.Access})
 	}
 }
@@ -18,7 +18,7 @@ func VerifyEmailWithService(svc services.AuthService) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
-		if err := svc.VerifyEmail(c.Request.Context(), req.Email, req.Code); err != nil {
+		if err := svc.VerifyEmail(c.Request.Context(), req.Email, req.Code, clientMeta(c)); err != nil {
 			if err.Error() == "not_found" {
 				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 				return
@@ -27,6 +27,10 @@ func VerifyEmailWithService(svc services.AuthService) gin.HandlerFunc {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired code"})
 				return
 			}
+			if err.Error() == "too_many_attempts" || err.Error() == "too_many_code_attempts" {
+				RespondTooManyAttempts(c, err)
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 			return
 		}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCollectionNameLength = 100
+
+// @name BookmarkRequest
+type bookmarkRequest struct {
+	CollectionID *string `json:"collection_id"`
+}
+
+// @name CollectionRequest
+type collectionRequest struct {
+	Name string `json:"name"`
+}
+
+// @name BookmarkItem
+type bookmarkItem struct {
+	ID           string    `json:"id"`
+	CollectionID *string   `json:"collection_id"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+	Post         feedItem  `json:"post"`
+}
+
+// @name BookmarksResponse
+type bookmarksResponse struct {
+	Bookmarks  []bookmarkItem `json:"bookmarks"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func respondBookmarkError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	switch err.Error() {
+	case "collection_not_found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// @Summary Bookmark a post
+// @Description Privately save a post, optionally into one of your collections. Bookmarking a saved post again keeps it, moving it if collection_id is given.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param body body bookmarkRequest false "Collection"
+// @Success 200 {object} models.Bookmark
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /post/{id}/bookmark [post]
+func BookmarkPost(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req bookmarkRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+		}
+
+		bookmark, err := bookmarks.Bookmark(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.CollectionID)
+		if err != nil {
+			respondBookmarkError(c, err, "Failed to bookmark post")
+			return
+		}
+		c.JSON(http.StatusOK, bookmark)
+	}
+}
+
+// @Summary Remove a bookmark
+// @Description Succeeds whether or not the post was bookmarked.
+// @Tags bookmarks
+// @Param id path string true "Post ID"
+// @Success 204
+// @Security BearerAuth
+// @Router /post/{id}/bookmark [delete]
+func UnbookmarkPost(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := bookmarks.Unbookmark(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary List bookmarks
+// @Description Bookmarked posts, most recently saved first. Bookmarks of deleted posts are left out.
+// @Tags bookmarks
+// @Produce json
+// @Param collection_id query string false "Only bookmarks in this collection"
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} bookmarksResponse
+// @Security BearerAuth
+// @Router /bookmarks [get]
+func GetBookmarks(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
+
+		var after *repository.FeedCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.FeedCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil || after.ID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		var collectionID *string
+		if id := c.Query("collection_id"); id != "" {
+			collectionID = &id
+		}
+
+		posts, err := bookmarks.List(c.Request.Context(), c.GetString("userID"), collectionID, after, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+			return
+		}
+
+		resp := bookmarksResponse{Bookmarks: make([]bookmarkItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			last := posts[len(posts)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.FeedCursor{CreatedAt: last.BookmarkedAt, ID: last.BookmarkID})
+		}
+		for _, p := range posts {
+			resp.Bookmarks = append(resp.Bookmarks, bookmarkItem{
+				ID:           p.BookmarkID,
+				CollectionID: p.CollectionID,
+				BookmarkedAt: p.BookmarkedAt,
+				Post:         toFeedItem(p.FeedPost),
+			})
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary Move a bookmark
+// @Description Move a bookmark into a collection, or out of any collection with collection_id null.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param post_id path string true "Post ID"
+// @Param body body bookmarkRequest true "Target collection"
+// @Success 200 {object} models.Bookmark
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /bookmarks/{post_id} [patch]
+func MoveBookmark(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req bookmarkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		bookmark, err := bookmarks.Move(c.Request.Context(), c.GetString("userID"), c.Param("post_id"), req.CollectionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+				return
+			}
+			respondBookmarkError(c, err, "Failed to move bookmark")
+			return
+		}
+		c.JSON(http.StatusOK, bookmark)
+	}
+}
+
+func collectionName(c *gin.Context) (string, bool) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return "", false
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1 to 100 characters"})
+		return "", false
+	}
+	return name, true
+}
+
+// @Summary List collections
+// @Description Your bookmark collections by name, with the number of posts in each.
+// @Tags bookmarks
+// @Produce json
+// @Success 200 {array} repository.CollectionSummary
+// @Security BearerAuth
+// @Router /bookmarks/collections [get]
+func GetCollections(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collections, err := bookmarks.ListCollections(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"collections": collections})
+	}
+}
+
+// @Summary Create a collection
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param body body collectionRequest true "Collection name"
+// @Success 201 {object} models.Collection
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /bookmarks/collections [post]
+func CreateCollection(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := collectionName(c)
+		if !ok {
+			return
+		}
+		collection, err := bookmarks.CreateCollection(c.Request.Context(), c.GetString("userID"), name)
+		if err != nil {
+			if err.Error() == "collection_exists" {
+				c.JSON(http.StatusConflict, gin.H{"error": "You already have a collection with this name"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+			return
+		}
+		c.JSON(http.StatusCreated, collection)
+	}
+}
+
+// @Summary Rename a collection
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Param body body collectionRequest true "New name"
+// @Success 200 {object} models.Collection
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /bookmarks/collections/{id} [patch]
+func RenameCollection(bookmarks repository.BookmarkRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := collectionName(c)
+		if !ok {
+			return
+		}
+		collection, err := bookmarks.RenameCollection(c.Request.Context(), c.GetString("userID"), c.Param("id"), name)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+				return
+			}
+			if err.Error() == "collection_exists" {
+				c.JSON(http.StatusConflict, gin.H{"error": "You already have a collection with this name"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename collection"})
+			return
+		}
+		c.JSON(http.StatusOK, collection)
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -12,9 +11,9 @@ import (
 	"modern-social-media/internal/auth"
 	imodels "modern-social-media/internal/models"
 	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -110,9 +109,10 @@ func (h *Hub) sendToUser(userID string, payload any) {
 var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
 
 type ChatWSDeps struct {
-	Models    repository.Models
-	JWTSecret string
-	Hub       *Hub
+	Models   repository.Models
+	Keys     *auth.KeyManager
+	Hub      *Hub
+	Mentions *services.MentionService
 }
 
 func ChatWSHandler(deps ChatWSDeps) gin.HandlerFunc {
@@ -124,26 +124,18 @@ func ChatWSHandler(deps ChatWSDeps) gin.HandlerFunc {
 			return
 		}
 
-		var claims auth.AccessClaims
-		token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(deps.JWTSecret), nil
-		})
-
+		claims, err := deps.Keys.ParseAccess(tokenStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		if claims.SessionID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
 			return
 		}
-
-		if err := claims.Validate(); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token type"})
+		if active, err := deps.Models.Sessions.IsActive(c.Request.Context(), claims.SessionID, claims.UserID); err != nil || !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
 			return
 		}
 
@@ -215,8 +207,9 @@ func reader(ctx *gin.Context, deps ChatWSDeps, cn *connection) {
 					deps.Hub.sendToUser(cn.userID, WSEvent{Type: "error", Data: mustJSON(gin.H{"error": "save_failed"})})
 					continue
 				}
+				msg.Mentions = syncMessageMentions(ctx, deps.Mentions, msg)
 				peers := getConversationPeers(ctx, deps.Models, p.ConversationID, "")
-				deps.Hub.broadcastToUsers(peers, WSEvent{Type: "message", Data: mustJSON(gin.H{"conversation_id": p.ConversationID, "sender_id": cn.userID, "body": p.Body, "created_at": time.Now().Unix()})})
+				deps.Hub.broadcastToUsers(peers, WSEvent{Type: "message", Data: mustJSON(gin.H{"conversation_id": p.ConversationID, "sender_id": cn.userID, "body": p.Body, "mentions": msg.Mentions, "created_at": time.Now().Unix()})})
 			}
 		case "read":
 			var p WSReadPayload
@@ -286,7 +279,7 @@ type sendDirectBody struct {
 	Body string `json:"body"`
 }
 
-func SendDirectMessage(repos repository.Models, hub *Hub) gin.HandlerFunc {
+func SendDirectMessage(repos repository.Models, hub *Hub, mentions *services.MentionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		from := c.GetString("userID")
 		to := c.Param("user_id")
@@ -305,7 +298,8 @@ func SendDirectMessage(repos repository.Models, hub *Hub) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed"})
 			return
 		}
-		hub.broadcastToUsers([]string{from, to}, WSEvent{Type: "message", Data: mustJSON(gin.H{"conversation_id": conv.ID, "sender_id": from, "body": req.Body, "created_at": time.Now().Unix()})})
+		msg.Mentions = syncMessageMentions(c, mentions, msg)
+		hub.broadcastToUsers([]string{from, to}, WSEvent{Type: "message", Data: mustJSON(gin.H{"conversation_id": conv.ID, "sender_id": from, "body": req.Body, "mentions": msg.Mentions, "created_at": time.Now().Unix()})})
 		c.JSON(http.StatusOK, gin.H{"message": msg})
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"modern-social-media/internal/models"
 	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +18,7 @@ func GetCommentsByPost(commentRepo repository.CommentRepository) gin.HandlerFunc
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Post id is required"})
 			return
 		}
-		comments, err := commentRepo.GetCommentsByPost(c.Request.Context(), postID)
+		comments, err := commentRepo.GetCommentsByPost(c.Request.Context(), postID, c.GetString("userID"))
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
@@ -37,7 +38,7 @@ func GetCommentById(commentRepo repository.CommentRepository) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Comment id is required"})
 			return
 		}
-		comment, err := commentRepo.GetCommentById(c.Request.Context(), commentID)
+		comment, err := commentRepo.GetCommentById(c.Request.Context(), commentID, c.GetString("userID"))
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
@@ -68,7 +69,7 @@ func GetCommentsByUser(commentRepo repository.CommentRepository) gin.HandlerFunc
 	}
 }
 
-func CreateComment(commentRepo repository.CommentRepository) gin.HandlerFunc {
+func CreateComment(commentRepo repository.CommentRepository, mentions *services.MentionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			Message string `json:"message"`
@@ -105,7 +106,30 @@ func CreateComment(commentRepo repository.CommentRepository) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 			return
 		}
+		comment.Mentions = syncCommentMentions(c, mentions, comment)
 
 		c.JSON(http.StatusCreated, comment)
 	}
 }
+
+func DeleteComment(commentRepo repository.CommentRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uidAny, ok := c.Get("userID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		userID, _ := uidAny.(string)
+
+		if err := commentRepo.DeleteCommentByUser(c.Request.Context(), c.Param("id"), userID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"modern-social-media/internal/auth"
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dataExportInterval = 24 * time.Hour
+
+// @name DataExportResponse
+type dataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+func toDataExportResponse(e *models.DataExport) dataExportResponse {
+	resp := dataExportResponse{
+		ID:          e.ID,
+		Status:      string(e.Status),
+		SizeBytes:   e.SizeBytes,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+	if dataExportAvailable(e) {
+		resp.DownloadURL = "/api/v1/user/me/export/" + e.ID + "/download"
+	}
+	return resp
+}
+
+func dataExportAvailable(e *models.DataExport) bool {
+	return e.Status == models.DataExportReady && e.FilePath != "" && e.ExpiresAt != nil && time.Now().Before(*e.ExpiresAt)
+}
+
+// @Summary Request data export
+// @Description Queue an archive with everything stored about the authenticated user. The user is notified and emailed a download link when it is ready. One export per 24 hours; failed exports don't count.
+// @Tags users
+// @Produce json
+// @Success 202 {object} dataExportResponse
+// @Failure 429 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /user/me/export [post]
+func RequestDataExport(exports repository.DataExportRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		export, err := exports.CreateForUser(c.Request.Context(), c.GetString("userID"), time.Now().Add(-dataExportInterval))
+		if err != nil {
+			if err.Error() == "export_rate_limited" {
+				retryAfter := int(math.Ceil(time.Until(export.CreatedAt.Add(dataExportInterval)).Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "Only one export per day is allowed", "retry_after": retryAfter, "export": toDataExportResponse(export)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, toDataExportResponse(export))
+	}
+}
+
+// @Summary List data exports
+// @Description Recent export requests of the authenticated user with their status
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string][]dataExportResponse
+// @Security BearerAuth
+// @Router /user/me/export [get]
+func ListDataExports(exports repository.DataExportRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, err := exports.ListByUser(c.Request.Context(), c.GetString("userID"), 10)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get exports"})
+			return
+		}
+
+		response := make([]dataExportResponse, len(items))
+		for i := range items {
+			response[i] = toDataExportResponse(&items[i])
+		}
+		c.JSON(http.StatusOK, gin.H{"exports": response})
+	}
+}
+
+// @Summary Download data export
+// @Description Download a ready export archive of the authenticated user
+// @Tags users
+// @Produce application/zip
+// @Param id path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /user/me/export/{id}/download [get]
+func DownloadDataExport(exports repository.DataExportRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		export, err := exports.GetByIDForUser(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+		serveDataExport(c, export)
+	}
+}
+
+// @Summary Download data export by link
+// @Description Download an export archive using the link sent by email. The link stops working when the export expires.
+// @Tags users
+// @Produce application/zip
+// @Param id path string true "Export ID"
+// @Param token query string true "Download token"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Router /exports/{id}/download [get]
+func DownloadDataExportByToken(exports repository.DataExportRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		export, err := exports.GetByID(c.Request.Context(), c.Param("id"))
+		if err != nil || token == "" || export.TokenHash == "" ||
+			subtle.ConstantTimeCompare([]byte(auth.HashToken(token)), []byte(export.TokenHash)) != 1 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+		serveDataExport(c, export)
+	}
+}
+
+func serveDataExport(c *gin.Context, export *models.DataExport) {
+	if export.Status != models.DataExportReady && export.Status != models.DataExportExpired {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready", "status": export.Status})
+		return
+	}
+	if !dataExportAvailable(export) {
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired"})
+		return
+	}
+	if _, err := os.Stat(export.FilePath); err != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(export.FilePath, "export-"+export.CreatedAt.Format("2006-01-02")+".zip")
+}
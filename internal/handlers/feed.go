@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @name FeedItem
+type feedItem struct {
+	PostResponse
+	Author       actorInfo `json:"author"`
+	LikedByMe    bool      `json:"liked_by_me"`
+	RepostedByMe bool      `json:"reposted_by_me"`
+
+	// Set when the item is in the feed because someone the viewer follows
+	// reposted it.
+	RepostID   *string    `json:"repost_id,omitempty"`
+	RepostedBy *actorInfo `json:"reposted_by,omitempty"`
+	RepostedAt *time.Time `json:"reposted_at,omitempty"`
+}
+
+func toFeedItem(p repository.FeedPost) feedItem {
+	return feedItem{
+		PostResponse: PostResponse{
+			ID:        p.ID,
+			UserID:    p.UserID,
+			Content:   p.Content,
+			ImageURL:  p.ImageURL,
+			Media:     p.Media,
+			Mentions:  p.Mentions,
+			Likes:     p.LikesCount,
+			Comments:  p.CommentsCount,
+			Reposts:   p.RepostsCount,
+			Audience:  p.Audience,
+			CreatedAt: p.CreatedAt,
+
+			EditedAt:      p.EditedAt,
+			RevisionCount: p.RevisionCount,
+
+			BookmarkedByMe: p.BookmarkedByMe,
+
+			QuotedPostID: p.QuotedPostID,
+			QuotedPost:   quotedPostFromPreview(p.QuotedPostID, p.QuotedPost),
+		},
+		Author: actorInfo{
+			ID:         p.UserID,
+			Username:   p.AuthorUsername,
+			FirstName:  p.AuthorFirstName,
+			LastName:   p.AuthorLastName,
+			AvatarURL:  p.AuthorAvatarURL,
+			IsVerified: p.AuthorIsVerified,
+		},
+		LikedByMe:    p.LikedByMe,
+		RepostedByMe: p.RepostedByMe,
+	}
+}
+
+func toFeedEntryItem(e repository.FeedEntry) feedItem {
+	item := toFeedItem(e.FeedPost)
+	if e.RepostID != nil && e.ReposterID != nil {
+		at := e.EntryAt
+		item.RepostID = e.RepostID
+		item.RepostedAt = &at
+		item.RepostedBy = &actorInfo{ID: *e.ReposterID}
+		if e.ReposterUsername != nil {
+			item.RepostedBy.Username = *e.ReposterUsername
+		}
+		if e.ReposterFirstName != nil {
+			item.RepostedBy.FirstName = *e.ReposterFirstName
+		}
+		if e.ReposterLastName != nil {
+			item.RepostedBy.LastName = *e.ReposterLastName
+		}
+		if e.ReposterAvatarURL != nil {
+			item.RepostedBy.AvatarURL = *e.ReposterAvatarURL
+		}
+		if e.ReposterIsVerified != nil {
+			item.RepostedBy.IsVerified = *e.ReposterIsVerified
+		}
+	}
+	return item
+}
+
+// @name FeedResponse
+type feedResponse struct {
+	Posts      []feedItem `json:"posts"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// @Summary Home feed
+// @Description Posts and reposts of accounts the authenticated user follows plus their own, newest first. A repost brings the post back at the time it was reposted, with reposted_by set.
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} feedResponse
+// @Security BearerAuth
+// @Router /feed [get]
+func GetFeed(postRepo repository.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
+
+		var after *repository.FeedCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.FeedCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil || after.ID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		posts, err := postRepo.Feed(c.Request.Context(), c.GetString("userID"), after, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+			return
+		}
+
+		resp := feedResponse{Posts: make([]feedItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			last := posts[len(posts)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.FeedCursor{CreatedAt: last.EntryAt, ID: last.EntryID})
+		}
+		for _, e := range posts {
+			resp.Posts = append(resp.Posts, toFeedEntryItem(e))
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @name ForYouItem
+type forYouItem struct {
+	feedItem
+	Score *services.ScoreBreakdown `json:"score,omitempty"`
+}
+
+// @name ForYouResponse
+type forYouResponse struct {
+	Posts      []forYouItem `json:"posts"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// @Summary For You feed
+// @Description Recent posts from across the network ranked by engagement, recency and the viewer's affinity with the author. With debug=true each item carries its score breakdown; this needs the content:moderate permission unless FEED_DEBUG is enabled.
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param debug query bool false "Include score breakdowns"
+// @Success 200 {object} forYouResponse
+// @Security BearerAuth
+// @Router /feed/for-you [get]
+func GetForYouFeed(ranker *services.FeedRanker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
+		after := services.RankCursor{AsOf: time.Now()}
+		if raw := c.Query("cursor"); raw != "" {
+			after = services.RankCursor{}
+			if err := utils.DecodeCursor(raw, &after); err != nil || after.ID == "" || after.AsOf.IsZero() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		debug, _ := strconv.ParseBool(c.Query("debug"))
+		if debug && !ranker.Debug && !slices.Contains(c.GetStringSlice("permissions"), string(models.PermContentModerate)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		posts, err := ranker.Rank(c.Request.Context(), c.GetString("userID"), after, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+			return
+		}
+
+		resp := forYouResponse{Posts: make([]forYouItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			resp.NextCursor = utils.EncodeCursor(posts[len(posts)-1].CursorAfter(after.AsOf))
+		}
+		for _, p := range posts {
+			item := forYouItem{feedItem: toFeedItem(p.FeedPost)}
+			if debug {
+				score := p.Score
+				item.Score = &score
+			}
+			resp.Posts = append(resp.Posts, item)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -254,3 +254,33 @@ func GetMyFollowing(repo repository.FollowRepository) gin.HandlerFunc {
 		c.JSON(http.StatusOK, users)
 	}
 }
+
+func GetCloseFriends(repo repository.FollowRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		users, err := repo.GetCloseFriends(c.Request.Context(), c.GetString("userID"), limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, users)
+	}
+}
+
+// SetCloseFriend returns a handler that adds or removes the
+// follower in :id from the caller's close friends.
+func SetCloseFriend(repo repository.FollowRepository, closeFriend bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := repo.SetCloseFriend(c.Request.Context(), c.GetString("userID"), c.Param("id"), closeFriend)
+		if err != nil {
+			if err.Error() == "not_a_follower" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Only your followers can be close friends"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update close friends"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"close_friend": closeFriend})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @name HashtagPostsResponse
+type hashtagPostsResponse struct {
+	Tag        string     `json:"tag"`
+	Posts      []feedItem `json:"posts"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// @Summary Posts with a hashtag
+// @Description Posts tagged with the hashtag, newest first. The tag is matched case-insensitively, with or without the leading '#'.
+// @Tags hashtags
+// @Produce json
+// @Param tag path string true "Hashtag"
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} hashtagPostsResponse
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tags/{tag}/posts [get]
+func GetHashtagPosts(tagRepo repository.HashtagRepository, postRepo repository.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := utils.NormalizeHashtag(c.Param("tag"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hashtag not found"})
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
+
+		var after *repository.FeedCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.FeedCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil || after.ID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		if _, err := tagRepo.GetByName(c.Request.Context(), name); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Hashtag not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag"})
+			return
+		}
+
+		posts, err := postRepo.ByHashtag(c.Request.Context(), c.GetString("userID"), name, after, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+			return
+		}
+
+		resp := hashtagPostsResponse{Tag: name, Posts: make([]feedItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			last := posts[len(posts)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+		for _, p := range posts {
+			resp.Posts = append(resp.Posts, toFeedItem(p))
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @name TrendingHashtagsResponse
+type trendingHashtagsResponse struct {
+	Tags []models.TrendingHashtag `json:"tags"`
+}
+
+// @Summary Trending hashtags
+// @Description Tags picking up fastest right now, from the latest scheduled snapshot
+// @Tags hashtags
+// @Produce json
+// @Param limit query int false "Limit" default(10)
+// @Success 200 {object} trendingHashtagsResponse
+// @Security BearerAuth
+// @Router /tags/trending [get]
+func GetTrendingHashtags(tagRepo repository.HashtagRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if limit <= 0 || limit > 50 {
+			limit = 10
+		}
+
+		tags, err := tagRepo.Trending(c.Request.Context(), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending hashtags"})
+			return
+		}
+		if tags == nil {
+			tags = []models.TrendingHashtag{}
+		}
+
+		c.JSON(http.StatusOK, trendingHashtagsResponse{Tags: tags})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"modern-social-media/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens issued by this service
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKSet
+// @Router /.well-known/jwks.json [get]
+func JWKS(keys *auth.KeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The helpers below resolve mentions once the post, comment or message is
+// already saved. A failure there shouldn't fail a write that succeeded, so it
+// is logged and the entity goes out without mentions.
+
+func syncPostMentions(c *gin.Context, mentions *services.MentionService, post *models.Post) []models.Mention {
+	list, err := mentions.SyncPost(c.Request.Context(), post.ID, post.UserID, post.Content)
+	if err != nil {
+		log.Printf("Mention sync for post %s failed: %v", post.ID, err)
+		return []models.Mention{}
+	}
+	return list
+}
+
+func syncCommentMentions(c *gin.Context, mentions *services.MentionService, comment *models.Comment) []models.Mention {
+	list, err := mentions.SyncComment(c.Request.Context(), comment.ID, comment.PostID, comment.UserID, comment.Message)
+	if err != nil {
+		log.Printf("Mention sync for comment %s failed: %v", comment.ID, err)
+		return []models.Mention{}
+	}
+	return list
+}
+
+func syncMessageMentions(c *gin.Context, mentions *services.MentionService, msg *models.Message) []models.Mention {
+	list, err := mentions.SyncMessage(c.Request.Context(), msg)
+	if err != nil {
+		log.Printf("Mention sync for message %s failed: %v", msg.ID, err)
+		return []models.Mention{}
+	}
+	return list
+}
@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"modern-social-media/internal/models"
 	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
+	"modern-social-media/internal/utils"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,70 +16,113 @@ import (
 
 // @name PostResponse
 type PostResponse struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Content   string    `json:"content"`
-	ImageURL  string    `json:"image_url"`
-	Likes     int       `json:"likes_count"`
-	Comments  int       `json:"comments_count"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string              `json:"id"`
+	UserID    string              `json:"user_id"`
+	Content   string              `json:"content"`
+	ImageURL  string              `json:"image_url"`
+	Media     []models.PostMedia  `json:"media"`
+	Mentions  []models.Mention    `json:"mentions"`
+	Likes     int                 `json:"likes_count"`
+	Comments  int                 `json:"comments_count"`
+	Reposts   int                 `json:"reposts_count"`
+	Audience  models.PostAudience `json:"audience"`
+	CreatedAt time.Time           `json:"created_at"`
+
+	EditedAt      *time.Time `json:"edited_at"`
+	RevisionCount int        `json:"revision_count"`
+
+	BookmarkedByMe bool `json:"bookmarked_by_me"`
+
+	QuotedPostID *string            `json:"quoted_post_id"`
+	QuotedPost   *quotedPostResponse `json:"quoted_post,omitempty"`
 }
 
 // @Summary Get user posts
-// @Description Get posts by user
+// @Description The caller's own posts, newest first
 // @Tags posts
 // @Produce json
-// @Success 200 {array} PostResponse
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} feedResponse
+// @Security BearerAuth
 // @Router /posts [get]
 func GetPostsByUser(postRepo repository.PostRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		uidAny, ok := c.Get("userID")
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-			return
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
 		}
 
-		userID, _ := uidAny.(string)
+		var after *repository.FeedCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.FeedCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil || after.ID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
 
-		posts, err := postRepo.GetPostsByUser(c.Request.Context(), userID)
+		posts, err := postRepo.GetOwnPosts(c.Request.Context(), c.GetString("userID"), after, limit+1)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
 			return
 		}
 
-		var response []PostResponse
+		resp := feedResponse{Posts: make([]feedItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			last := posts[len(posts)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
 		for _, p := range posts {
-			response = append(response, PostResponse{
-				ID:        p.ID,
-				UserID:    p.UserID,
-				Content:   p.Content,
-				ImageURL:  p.ImageURL,
-				Likes:     p.LikesCount,
-				Comments:  p.CommentsCount,
-				CreatedAt: p.CreatedAt,
-			})
+			resp.Posts = append(resp.Posts, toFeedItem(p))
 		}
-		c.JSON(http.StatusOK, response)
+
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
-
 // @Summary Get all posts
-// @Description Get posts post all users
+// @Description Posts from all users that the caller is allowed to see, newest first
 // @Tags posts
 // @Produce json
-// @Success 200 {array} PostResponse
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} feedResponse
 // @Router /posts/all [get]
 func GetAllPosts(postRepo repository.PostRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		posts, err := postRepo.GetAllPosts(c.Request.Context())
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
 
+		var after *repository.FeedCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.FeedCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil || after.ID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		posts, err := postRepo.GetAllPosts(c.Request.Context(), c.GetString("userID"), after, limit+1)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
 			return
 		}
 
-		c.JSON(http.StatusOK, posts)
+		resp := feedResponse{Posts: make([]feedItem, 0, limit)}
+		if len(posts) > limit {
+			posts = posts[:limit]
+			last := posts[len(posts)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+		for _, p := range posts {
+			resp.Posts = append(resp.Posts, toFeedItem(p))
+		}
+
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
@@ -90,26 +134,28 @@ type CreatePostRequest struct {
 
 // @name UpdatePostRequest
 type UpdatePostRequest struct {
-	Content  string `json:"content"`
-	ImageURL string `json:"imageUrl"`
+	Content string `json:"content"`
 }
 
 // @Summary Create post
-// @Description Create a new post with optional image
+// @Description Create a new post with up to 10 attachments: JPEG, PNG, GIF, WebP images (10MB each) or MP4, WebM videos (50MB each)
 // @Tags posts
 // @Accept multipart/form-data
 // @Produce json
 // @Param content formData string true "Post content"
-// @Param image formData file false "Post image"
+// @Param media formData []file false "Attachments, in display order" collectionFormat(multi)
+// @Param alt formData []string false "Alt text for each attachment, in the same order" collectionFormat(multi)
+// @Param image formData file false "Single image (legacy)"
+// @Param quoted_post_id formData string false "ID of the post being quoted"
+// @Param audience formData string false "Who can see the post: public, followers, close_friends or private" default(public)
 // @Success 201 {object} PostResponse
 // @Router /posts [post]
-func CreatePost(postRepo repository.PostRepository) gin.HandlerFunc {
+func CreatePost(postRepo repository.PostRepository, mentions *services.MentionService, reposts *services.RepostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		const maxUploadSize = 5 << 20
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPostUploadSize)
 
-		if err := c.Request.ParseMultipartForm(maxUploadSize); err != nil {
-			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request too large (max 5MB)"})
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request too large (max 100MB)"})
 			return
 		}
 
@@ -126,35 +172,46 @@ func CreatePost(postRepo repository.PostRepository) gin.HandlerFunc {
 		}
 		userID, _ := uidAny.(string)
 
-		var imageURL string
-		file, err := c.FormFile("image")
-		if err == nil {
-			ext := strings.ToLower(filepath.Ext(filepath.Base(file.Filename)))
-			
-			if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Only image files are allowed (.jpg, .png, .gif, .webp)"})
-				return
-			}
-
-			os.MkdirAll("uploads/posts", os.ModePerm)
-
-			filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-			path := filepath.Join("uploads", "posts", filename)
+		audience := models.PostAudience(c.DefaultPostForm("audience", string(models.PostAudiencePublic)))
+		if !audience.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audience"})
+			return
+		}
 
-			if err := c.SaveUploadedFile(file, path); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		var quoted *models.Post
+		if quotedID := c.PostForm("quoted_post_id"); quotedID != "" {
+			q, err := postRepo.GetById(c.Request.Context(), quotedID, userID)
+			if err != nil || !q.User.IsActive {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Quoted post not found"})
 				return
 			}
-			imageURL = "/uploads/posts/" + filename
+			quoted = q
+		}
+
+		files, alts := postMediaFiles(c.Request.MultipartForm)
+		if len(files) > maxPostMedia {
+			respondPostMediaError(c, errors.New("too_many_media"))
+			return
+		}
+		media, err := savePostMedia(files, alts)
+		if err != nil {
+			respondPostMediaError(c, err)
+			return
 		}
 
 		post := &models.Post{
 			UserID:   userID,
 			Content:  content,
-			ImageURL: imageURL,
+			ImageURL: firstImageURL(media),
+			Media:    media,
+			Audience: audience,
+		}
+		if quoted != nil {
+			post.QuotedPostID = &quoted.ID
 		}
 
 		if err := postRepo.CreatePost(c.Request.Context(), post); err != nil {
+			removePostMediaFiles(media)
 			if strings.Contains(err.Error(), "SQLSTATE 23503") {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "userId error"})
 				return
@@ -162,15 +219,24 @@ func CreatePost(postRepo repository.PostRepository) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		post.Mentions = syncPostMentions(c, mentions, post)
+		if quoted != nil {
+			reposts.NotifyQuote(c.Request.Context(), post, quoted)
+		}
 
-		full, err := postRepo.GetById(c.Request.Context(), post.ID)
+		full, err := postRepo.GetById(c.Request.Context(), post.ID, post.UserID)
 		if err != nil {
 			c.JSON(http.StatusCreated, PostResponse{
 				ID:        post.ID,
 				UserID:    post.UserID,
 				Content:   post.Content,
 				ImageURL:  post.ImageURL,
+				Media:     post.Media,
+				Mentions:  post.Mentions,
+				Audience:  post.Audience,
 				CreatedAt: post.CreatedAt,
+
+				QuotedPostID: post.QuotedPostID,
 			})
 			return
 		}
@@ -180,26 +246,46 @@ func CreatePost(postRepo repository.PostRepository) gin.HandlerFunc {
 			UserID:    full.UserID,
 			Content:   full.Content,
 			ImageURL:  full.ImageURL,
+			Media:     full.Media,
+			Mentions:  full.Mentions,
 			Likes:     full.LikesCount,
 			Comments:  full.CommentsCount,
+			Reposts:   full.RepostsCount,
+			Audience:  full.Audience,
 			CreatedAt: full.CreatedAt,
+
+			EditedAt:      full.EditedAt,
+			RevisionCount: full.RevisionCount,
+
+			QuotedPostID: full.QuotedPostID,
+			QuotedPost:   quotedPostFromModel(full),
 		})
 	}
 }
 
 // @Summary Update post
-// @Description Update a post
+// @Description Update a post. A JSON body changes only the text; image_url always follows the first image attachment. A multipart body can also change attachments: keep_media lists the attachment IDs to keep, in their new order (omit it to keep all), and media adds new files after them.
 // @Tags posts
 // @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Post ID"
-// @Param request body UpdatePostRequest true "Post content"
+// @Param request body UpdatePostRequest false "Post content"
+// @Param content formData string false "Post content"
+// @Param keep_media formData []string false "Attachment IDs to keep, in order" collectionFormat(multi)
+// @Param media formData []file false "New attachments" collectionFormat(multi)
+// @Param alt formData []string false "Alt text for each new attachment" collectionFormat(multi)
 // @Success 200 {object} PostResponse
 // @Router /posts/{id} [put]
-func UpdatePost(postRepo repository.PostRepository) gin.HandlerFunc {
+func UpdatePost(postRepo repository.PostRepository, bookmarks repository.BookmarkRepository, mentions *services.MentionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			updatePostWithMedia(c, postRepo, bookmarks, mentions, id)
+			return
+		}
+
 		var req UpdatePostRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
@@ -220,13 +306,12 @@ func UpdatePost(postRepo repository.PostRepository) gin.HandlerFunc {
 		userID, _ := uidAny.(string)
 
 		post := &models.Post{
-			ID:       id,
-			UserID:   userID,
-			Content:  req.Content,
-			ImageURL: req.ImageURL,
+			ID:      id,
+			UserID:  userID,
+			Content: req.Content,
 		}
 
-		if err := postRepo.UpdatePostByUser(c.Request.Context(), id, userID, post); err != nil {
+		if err := postRepo.UpdatePostByUser(c.Request.Context(), id, userID, req.Content); err != nil {
 			if strings.Contains(strings.ToLower(err.Error()), "record not found") {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 				return
@@ -238,26 +323,171 @@ func UpdatePost(postRepo repository.PostRepository) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		full, err := postRepo.GetById(c.Request.Context(), id)
+		respondUpdatedPost(c, postRepo, bookmarks, mentions, post)
+	}
+}
+
+func updatePostWithMedia(c *gin.Context, postRepo repository.PostRepository, bookmarks repository.BookmarkRepository, mentions *services.MentionService, id string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPostUploadSize)
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request too large (max 100MB)"})
+		return
+	}
+	userID := c.GetString("userID")
+
+	existing, err := postRepo.GetById(c.Request.Context(), id, userID)
+	if err != nil || existing.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	content := existing.Content
+	if values, ok := c.Request.MultipartForm.Value["content"]; ok {
+		content = values[0]
+		if strings.TrimSpace(content) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Content is required"})
+			return
+		}
+	}
+
+	var keep []string
+	if values, ok := c.Request.MultipartForm.Value["keep_media"]; ok {
+		for _, v := range values {
+			if v != "" {
+				keep = append(keep, v)
+			}
+		}
+	} else {
+		for _, m := range existing.Media {
+			keep = append(keep, m.ID)
+		}
+	}
+
+	files, alts := postMediaFiles(c.Request.MultipartForm)
+	if len(keep)+len(files) > maxPostMedia {
+		respondPostMediaError(c, errors.New("too_many_media"))
+		return
+	}
+	added, err := savePostMedia(files, alts)
+	if err != nil {
+		respondPostMediaError(c, err)
+		return
+	}
+
+	if err := postRepo.UpdatePostWithMedia(c.Request.Context(), id, userID, content, keep, added); err != nil {
+		removePostMediaFiles(added)
+		if strings.Contains(strings.ToLower(err.Error()), "record not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		respondPostMediaError(c, err)
+		return
+	}
+
+	respondUpdatedPost(c, postRepo, bookmarks, mentions, &models.Post{ID: id, UserID: userID, Content: content, ImageURL: firstImageURL(added), Media: added})
+}
+
+// respondUpdatedPost reloads the post after an edit and re-resolves its
+// mentions against the new text.
+func respondUpdatedPost(c *gin.Context, postRepo repository.PostRepository, bookmarks repository.BookmarkRepository, mentions *services.MentionService, post *models.Post) {
+	full, err := postRepo.GetById(c.Request.Context(), post.ID, post.UserID)
+	if err != nil {
+		c.JSON(http.StatusOK, PostResponse{
+			ID:        post.ID,
+			UserID:    post.UserID,
+			Content:   post.Content,
+			ImageURL:  post.ImageURL,
+			Media:     post.Media,
+			Mentions:  post.Mentions,
+			Audience:  post.Audience,
+			CreatedAt: post.CreatedAt,
+		})
+		return
+	}
+	full.Mentions = syncPostMentions(c, mentions, full)
+	bookmarked, _ := bookmarks.BookmarkedPostIDs(c.Request.Context(), post.UserID, []string{full.ID})
+
+	c.JSON(http.StatusOK, PostResponse{
+		ID:        full.ID,
+		UserID:    full.UserID,
+		Content:   full.Content,
+		ImageURL:  full.ImageURL,
+		Media:     full.Media,
+		Mentions:  full.Mentions,
+		Likes:     full.LikesCount,
+		Comments:  full.CommentsCount,
+		Reposts:   full.RepostsCount,
+		Audience:  full.Audience,
+		CreatedAt: full.CreatedAt,
+
+		EditedAt:      full.EditedAt,
+		RevisionCount: full.RevisionCount,
+
+		BookmarkedByMe: bookmarked[full.ID],
+
+		QuotedPostID: full.QuotedPostID,
+		QuotedPost:   quotedPostFromModel(full),
+	})
+}
+
+// @name PostAudienceRequest
+type postAudienceRequest struct {
+	Audience models.PostAudience `json:"audience"`
+}
+
+// @Summary Change post audience
+// @Description Change who can see one of your posts: public, followers, close_friends or private. Mentions are re-resolved, so only users who can now see the post stay mentioned.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param body body postAudienceRequest true "New audience"
+// @Success 200 {object} PostResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /post/{id}/audience [patch]
+func SetPostAudience(postRepo repository.PostRepository, bookmarks repository.BookmarkRepository, mentions *services.MentionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req postAudienceRequest
+		if err := c.ShouldBindJSON(&req); err != nil || !req.Audience.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audience"})
+			return
+		}
+
+		userID := c.GetString("userID")
+		post, err := postRepo.SetAudience(c.Request.Context(), c.Param("id"), userID, req.Audience)
 		if err != nil {
-			c.JSON(http.StatusOK, PostResponse{
-				ID:        post.ID,
-				UserID:    post.UserID,
-				Content:   post.Content,
-				ImageURL:  post.ImageURL,
-				CreatedAt: post.CreatedAt,
-			})
+			if strings.Contains(strings.ToLower(err.Error()), "record not found") {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update audience"})
 			return
 		}
-		
+		post.Mentions = syncPostMentions(c, mentions, post)
+		bookmarked, _ := bookmarks.BookmarkedPostIDs(c.Request.Context(), userID, []string{post.ID})
+
 		c.JSON(http.StatusOK, PostResponse{
-			ID:        full.ID,
-			UserID:    full.UserID,
-			Content:   full.Content,
-			ImageURL:  full.ImageURL,
-			Likes:     full.LikesCount,
-			Comments:  full.CommentsCount,
-			CreatedAt: full.CreatedAt,
+			ID:        post.ID,
+			UserID:    post.UserID,
+			Content:   post.Content,
+			ImageURL:  post.ImageURL,
+			Media:     post.Media,
+			Mentions:  post.Mentions,
+			Likes:     post.LikesCount,
+			Comments:  post.CommentsCount,
+			Reposts:   post.RepostsCount,
+			Audience:  post.Audience,
+			CreatedAt: post.CreatedAt,
+
+			EditedAt:      post.EditedAt,
+			RevisionCount: post.RevisionCount,
+
+			BookmarkedByMe: bookmarked[post.ID],
+
+			QuotedPostID: post.QuotedPostID,
+			QuotedPost:   quotedPostFromModel(post),
 		})
 	}
 }
@@ -273,7 +503,8 @@ func DeletePostByUser(postRepo repository.PostRepository) gin.HandlerFunc {
 
 		userID, _ := uidAny.(string)
 
-		if err := postRepo.DeletePostByUser(c.Request.Context(), id, userID); err != nil {
+		err := postRepo.DeletePostByUser(c.Request.Context(), id, userID)
+		if err != nil {
 			if strings.Contains(strings.ToLower(err.Error()), "record not found") {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 				return
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"image"
+	_ "image/gif"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucsky/cuid"
+)
+
+const (
+	maxPostMedia      = 10
+	maxPostImageSize  = 10 << 20
+	maxPostVideoSize  = 50 << 20
+	maxPostUploadSize = 100 << 20
+	maxPostAltText    = 1000
+)
+
+// postMediaTypes maps the sniffed MIME type of an upload to its stored
+// extension. The client-supplied filename and Content-Type are ignored.
+var postMediaTypes = map[string]struct {
+	ext  string
+	kind models.MediaType
+}{
+	"image/jpeg": {".jpg", models.MediaTypeImage},
+	"image/png":  {".png", models.MediaTypeImage},
+	"image/gif":  {".gif", models.MediaTypeImage},
+	"image/webp": {".webp", models.MediaTypeImage},
+	"video/mp4":  {".mp4", models.MediaTypeVideo},
+	"video/webm": {".webm", models.MediaTypeVideo},
+}
+
+// postMediaFiles returns the uploaded attachments with their alt texts. The
+// legacy single "image" field is still accepted and goes first.
+func postMediaFiles(form *multipart.Form) ([]*multipart.FileHeader, []string) {
+	files := form.File["media"]
+	alts := form.Value["alt"]
+	if legacy := form.File["image"]; len(legacy) > 0 {
+		files = append([]*multipart.FileHeader{legacy[0]}, files...)
+		alts = append([]string{""}, alts...)
+	}
+	return files, alts
+}
+
+// savePostMedia validates and stores uploads under uploads/posts. alts[i]
+// belongs to files[i]; missing entries mean no alt text. On error nothing is
+// left on disk.
+func savePostMedia(files []*multipart.FileHeader, alts []string) ([]models.PostMedia, error) {
+	media := make([]models.PostMedia, 0, len(files))
+	for i, fh := range files {
+		alt := ""
+		if i < len(alts) {
+			alt = alts[i]
+		}
+		if utf8.RuneCountInString(alt) > maxPostAltText {
+			removePostMediaFiles(media)
+			return nil, errors.New("alt_too_long")
+		}
+		m, err := savePostMediaFile(fh)
+		if err != nil {
+			removePostMediaFiles(media)
+			return nil, err
+		}
+		m.Position = i
+		m.AltText = alt
+		media = append(media, *m)
+	}
+	return media, nil
+}
+
+func savePostMediaFile(fh *multipart.FileHeader) (*models.PostMedia, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	mimeType := http.DetectContentType(head[:n])
+	kind, ok := postMediaTypes[mimeType]
+	if !ok {
+		return nil, errors.New("unsupported_media")
+	}
+	limit := int64(maxPostImageSize)
+	if kind.kind == models.MediaTypeVideo {
+		limit = maxPostVideoSize
+	}
+	if fh.Size > limit {
+		return nil, errors.New("file_too_large")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	m := &models.PostMedia{Type: kind.kind, MimeType: mimeType, SizeBytes: fh.Size}
+	if kind.kind == models.MediaTypeImage {
+		cfg, _, err := image.DecodeConfig(f)
+		if err != nil {
+			return nil, errors.New("unsupported_media")
+		}
+		m.Width, m.Height = cfg.Width, cfg.Height
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join("uploads", "posts"), os.ModePerm); err != nil {
+		return nil, err
+	}
+	name := cuid.New() + kind.ext
+	dst, err := os.Create(filepath.Join("uploads", "posts", name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(dst, f); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return nil, err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return nil, err
+	}
+	m.URL = "/uploads/posts/" + name
+	return m, nil
+}
+
+func removePostMediaFiles(media []models.PostMedia) {
+	for _, m := range media {
+		utils.RemoveUpload(m.URL)
+	}
+}
+
+func firstImageURL(media []models.PostMedia) string {
+	for _, m := range media {
+		if m.Type == models.MediaTypeImage {
+			return m.URL
+		}
+	}
+	return ""
+}
+
+func respondPostMediaError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "unsupported_media":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, GIF, WebP images and MP4, WebM videos are allowed"})
+	case "file_too_large":
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Images are limited to 10MB and videos to 50MB"})
+	case "alt_too_long":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alt text is limited to 1000 characters"})
+	case "too_many_media":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A post can have at most 10 attachments"})
+	case "unknown_media":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep_media contains an attachment that does not belong to this post"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save media"})
+	}
+}
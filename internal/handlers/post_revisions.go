@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @name PostRevisionResponse
+type postRevisionResponse struct {
+	Number      int                  `json:"number"`
+	Content     string               `json:"content"`
+	ImageURL    string               `json:"image_url"`
+	Media       models.RevisionMedia `json:"media"`
+	PublishedAt time.Time            `json:"published_at"`
+	ReplacedAt  time.Time            `json:"replaced_at"`
+}
+
+// @name PostRevisionListResponse
+type postRevisionListResponse struct {
+	PostID        string                 `json:"post_id"`
+	EditedAt      *time.Time             `json:"edited_at"`
+	RevisionCount int                    `json:"revision_count"`
+	Revisions     []postRevisionResponse `json:"revisions"`
+}
+
+// @Summary Get post revisions
+// @Description Previous versions of a post, newest first, each with the attachments it was published with. The current version is not included.
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} postRevisionListResponse
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /post/{id}/revisions [get]
+func GetPostRevisions(postRepo repository.PostRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, err := postRepo.GetById(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		revisions, err := postRepo.ListRevisions(c.Request.Context(), post.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+			return
+		}
+
+		resp := postRevisionListResponse{
+			PostID:        post.ID,
+			EditedAt:      post.EditedAt,
+			RevisionCount: post.RevisionCount,
+			Revisions:     make([]postRevisionResponse, len(revisions)),
+		}
+		for i, r := range revisions {
+			resp.Revisions[i] = postRevisionResponse{
+				Number:      r.Number,
+				Content:     r.Content,
+				ImageURL:    r.ImageURL,
+				Media:       r.Media,
+				PublishedAt: r.PublishedAt,
+				ReplacedAt:  r.CreatedAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary Restore post revision
+// @Description Make an older version current again. Only the author can do this; the replaced version becomes a new revision. Posts with attachments keep their media and only get the text back.
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param number path int true "Revision number"
+// @Success 200 {object} PostResponse
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /post/{id}/revisions/{number}/restore [post]
+func RestorePostRevision(postRepo repository.PostRepository, bookmarks repository.BookmarkRepository, mentions *services.MentionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		number, err := strconv.Atoi(c.Param("number"))
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+			return
+		}
+
+		userID := c.GetString("userID")
+		if err := postRepo.RestoreRevision(c.Request.Context(), id, userID, number); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore revision"})
+			return
+		}
+
+		respondUpdatedPost(c, postRepo, bookmarks, mentions, &models.Post{ID: id, UserID: userID})
+	}
+}
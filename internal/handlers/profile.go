@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @name PublicProfileDTO
+type PublicProfileDTO struct {
+	ID             string   `json:"id"`
+	Username       string   `json:"username"`
+	FirstName      string   `json:"first_name"`
+	LastName       string   `json:"last_name"`
+	Bio            string   `json:"bio"`
+	AvatarURL      string   `json:"avatar_url"`
+	CoverURL       string   `json:"cover_url"`
+	IsVerified     bool     `json:"is_verified"`
+	FollowersCount int64    `json:"followers_count"`
+	FollowingCount int64    `json:"following_count"`
+	PostsCount     int64    `json:"posts_count"`
+	Skills         []string `json:"skills"`
+	IsFollowing    bool     `json:"is_following"`
+	IsOwner        bool     `json:"is_owner"`
+	CreatedAt      string   `json:"created_at"`
+
+	// Only populated when the viewer is the profile owner.
+	Email           *string `json:"email,omitempty"`
+	Is2FAEnabled    *bool   `json:"is_2fa_enabled,omitempty"`
+	Email2FAEnabled *bool   `json:"email_2fa_enabled,omitempty"`
+	TOTPEnabled     *bool   `json:"totp_enabled,omitempty"`
+}
+
+// @Summary Get public profile
+// @Description Get a user's public profile by username. Email and 2FA state are only included for the owner.
+// @Tags users
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} PublicProfileDTO
+// @Router /user/u/{username} [get]
+func GetPublicProfile(usersRepo repository.UserRepository, postRepo repository.PostRepository, followRepo repository.FollowRepository, skillRepo repository.SkillRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		user, err := usersRepo.GetByUsername(ctx, strings.TrimSpace(c.Param("username")))
+		if err != nil || !user.IsActive {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User Not Found"})
+			return
+		}
+
+		followersCount, _ := followRepo.CountFollowers(ctx, user.ID)
+		followingCount, _ := followRepo.CountFollowing(ctx, user.ID)
+		viewerID := c.GetString("userID")
+		postsCount, _ := postRepo.CountByUser(ctx, user.ID, viewerID)
+
+		skills, _ := skillRepo.GetAllSkills(ctx, user.ID)
+		skillNames := make([]string, 0, len(skills))
+		for _, s := range skills {
+			skillNames = append(skillNames, s.Name)
+		}
+
+		dto := PublicProfileDTO{
+			ID:             user.ID,
+			Username:       user.Username,
+			FirstName:      user.FirstName,
+			LastName:       user.LastName,
+			Bio:            user.Bio,
+			AvatarURL:      user.AvatarURL,
+			CoverURL:       user.CoverURL,
+			IsVerified:     user.IsVerified,
+			FollowersCount: followersCount,
+			FollowingCount: followingCount,
+			PostsCount:     postsCount,
+			Skills:         skillNames,
+			IsOwner:        viewerID == user.ID,
+			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+		}
+
+		if dto.IsOwner {
+			is2FA := user.Is2FAEnabled()
+			dto.Email = &user.Email
+			dto.Is2FAEnabled = &is2FA
+			dto.Email2FAEnabled = &user.Email2FAEnabled
+			dto.TOTPEnabled = &user.TOTPEnabled
+		} else if viewerID != "" {
+			dto.IsFollowing, _ = followRepo.IsFollowing(ctx, viewerID, user.ID)
+		}
+
+		c.JSON(http.StatusOK, dto)
+	}
+}
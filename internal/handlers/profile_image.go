@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucsky/cuid"
+)
+
+const maxProfileImageSize = 10 * 1024 * 1024
+
+type profileImageKind struct {
+	dir    string
+	ratioW int
+	ratioH int
+	widths []int
+	// primary is the width stored on the user and used when no srcset is supported.
+	primary int
+	get     func(u *models.User) string
+	set     func(u *models.User, url string)
+}
+
+var avatarImage = profileImageKind{
+	dir:     "avatars",
+	ratioW:  1,
+	ratioH:  1,
+	widths:  []int{64, 256, 1024},
+	primary: 256,
+	get:     func(u *models.User) string { return u.AvatarURL },
+	set:     func(u *models.User, url string) { u.AvatarURL = url },
+}
+
+var coverImage = profileImageKind{
+	dir:     "covers",
+	ratioW:  3,
+	ratioH:  1,
+	widths:  []int{600, 1200, 1800},
+	primary: 1200,
+	get:     func(u *models.User) string { return u.CoverURL },
+	set:     func(u *models.User, url string) { u.CoverURL = url },
+}
+
+// @name ProfileImageResponse
+type profileImageResponse struct {
+	URL    string `json:"url"`
+	SrcSet string `json:"srcset"`
+}
+
+// @Summary Upload avatar
+// @Description Upload a JPEG, PNG or WebP avatar. It is cropped to a square and stored in 64, 256 and 1024px sizes.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Avatar image"
+// @Success 200 {object} profileImageResponse
+// @Security BearerAuth
+// @Router /user/me/avatar [post]
+func UploadAvatar(usersRepo repository.UserRepository) gin.HandlerFunc {
+	return uploadProfileImage(usersRepo, avatarImage)
+}
+
+// @Summary Upload cover
+// @Description Upload a JPEG, PNG or WebP cover. It is cropped to 3:1 and stored in 600, 1200 and 1800px widths.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Cover image"
+// @Success 200 {object} profileImageResponse
+// @Security BearerAuth
+// @Router /user/me/cover [post]
+func UploadCover(usersRepo repository.UserRepository) gin.HandlerFunc {
+	return uploadProfileImage(usersRepo, coverImage)
+}
+
+func uploadProfileImage(usersRepo repository.UserRepository, kind profileImageKind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxProfileImageSize+1024*1024)
+		file, err := c.FormFile("image")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required"})
+			return
+		}
+		if file.Size > maxProfileImageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File size must be less than %dMB", maxProfileImageSize/(1024*1024))})
+			return
+		}
+
+		user, err := usersRepo.GetByID(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User Not Found"})
+			return
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+			return
+		}
+		data, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+			return
+		}
+
+		img, err := utils.DecodeImage(data)
+		if err != nil {
+			switch err.Error() {
+			case "image_too_large":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Image dimensions are too large"})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG and WebP images are allowed"})
+			}
+			return
+		}
+		cropped := utils.CropToAspect(img, kind.ratioW, kind.ratioH)
+
+		dir := filepath.Join("uploads", kind.dir, user.ID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+			return
+		}
+
+		setID := cuid.New()
+		written := make([]string, 0, len(kind.widths))
+		srcset := make([]string, 0, len(kind.widths))
+		for _, w := range kind.widths {
+			path := filepath.Join(dir, fmt.Sprintf("%s_%d.jpg", setID, w))
+			if err := utils.WriteJPEG(path, utils.Resize(cropped, w, w*kind.ratioH/kind.ratioW)); err != nil {
+				removeFiles(written)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+				return
+			}
+			written = append(written, path)
+			srcset = append(srcset, fmt.Sprintf("/%s %dw", filepath.ToSlash(path), w))
+		}
+
+		previous := kind.get(user)
+		url := fmt.Sprintf("/%s/%s_%d.jpg", filepath.ToSlash(dir), setID, kind.primary)
+		kind.set(user, url)
+		if err := usersRepo.UpdateUser(c.Request.Context(), user); err != nil {
+			removeFiles(written)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+		removeProfileImageSet(kind, user.ID, previous)
+
+		c.JSON(http.StatusOK, profileImageResponse{URL: url, SrcSet: strings.Join(srcset, ", ")})
+	}
+}
+
+// removeProfileImageSet deletes every size generated alongside previousURL.
+// URLs outside the user's own upload directory (default avatars, external
+// links) are left alone.
+func removeProfileImageSet(kind profileImageKind, userID, previousURL string) {
+	prefix := fmt.Sprintf("/uploads/%s/%s/", kind.dir, userID)
+	if !strings.HasPrefix(previousURL, prefix) {
+		return
+	}
+	name := strings.TrimPrefix(previousURL, prefix)
+	setID, _, ok := strings.Cut(name, "_")
+	if !ok || strings.ContainsAny(setID, `/\.`) {
+		return
+	}
+	matches, _ := filepath.Glob(filepath.Join("uploads", kind.dir, userID, setID+"_*.jpg"))
+	removeFiles(matches)
+}
+
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		_ = os.Remove(p)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @name QuotedPost
+type quotedPostResponse struct {
+	ID        string     `json:"id"`
+	Deleted   bool       `json:"deleted"`
+	Content   string     `json:"content,omitempty"`
+	ImageURL  string     `json:"image_url,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Author    *actorInfo `json:"author,omitempty"`
+}
+
+// quotedPostFromModel builds the preview of the post p quotes. When the
+// original is gone only a tombstone with its ID is left.
+func quotedPostFromModel(p *models.Post) *quotedPostResponse {
+	if p.QuotedPostID == nil {
+		return nil
+	}
+	q := p.QuotedPost
+	if q == nil || !q.User.IsActive {
+		return &quotedPostResponse{ID: *p.QuotedPostID, Deleted: true}
+	}
+	return &quotedPostResponse{
+		ID:        q.ID,
+		Content:   q.Content,
+		ImageURL:  q.ImageURL,
+		CreatedAt: &q.CreatedAt,
+		Author: &actorInfo{
+			ID:         q.UserID,
+			Username:   q.User.Username,
+			FirstName:  q.User.FirstName,
+			LastName:   q.User.LastName,
+			AvatarURL:  q.User.AvatarURL,
+			IsVerified: q.User.IsVerified,
+		},
+	}
+}
+
+func quotedPostFromPreview(quotedID *string, q repository.QuotedPostPreview) *quotedPostResponse {
+	if quotedID == nil {
+		return nil
+	}
+	if !q.Valid {
+		return &quotedPostResponse{ID: *quotedID, Deleted: true}
+	}
+	return &quotedPostResponse{
+		ID:        q.ID,
+		Content:   q.Content,
+		ImageURL:  q.ImageURL,
+		CreatedAt: &q.CreatedAt,
+		Author: &actorInfo{
+			ID:         q.UserID,
+			Username:   q.AuthorUsername,
+			FirstName:  q.AuthorFirstName,
+			LastName:   q.AuthorLastName,
+			AvatarURL:  q.AuthorAvatarURL,
+			IsVerified: q.AuthorIsVerified,
+		},
+	}
+}
+
+type repostResponse struct {
+	Reposted     bool `json:"reposted"`
+	RepostsCount int  `json:"reposts_count"`
+}
+
+// @Summary Repost
+// @Description Share a post with your followers. Reposting a post you already reposted changes nothing.
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} repostResponse
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /post/{id}/repost [post]
+func RepostPost(reposts *services.RepostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, err := reposts.Repost(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repost"})
+			return
+		}
+		c.JSON(http.StatusOK, repostResponse{Reposted: true, RepostsCount: post.RepostsCount})
+	}
+}
+
+// @Summary Undo repost
+// @Description Remove your repost of a post. Succeeds even if you hadn't reposted it.
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} repostResponse
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /post/{id}/repost [delete]
+func UnrepostPost(reposts *services.RepostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, err := reposts.Unrepost(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo repost"})
+			return
+		}
+		c.JSON(http.StatusOK, repostResponse{Reposted: false, RepostsCount: post.RepostsCount})
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"modern-social-media/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetTrash lists the caller's deleted posts, comments and stories that can
+// still be restored.
+func GetTrash(trash repository.TrashRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, err := trash.List(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	}
+}
+
+func RestoreTrashItem(trash repository.TrashRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := trash.Restore(c.Request.Context(), c.GetString("userID"), c.Param("type"), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+				return
+			}
+			switch err.Error() {
+			case "invalid_trash_type":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be post, comment or story"})
+			case "story_expired":
+				c.JSON(http.StatusConflict, gin.H{"error": "Story has expired and can no longer be restored"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item"})
+			}
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -1,34 +1,43 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
 
 	"modern-social-media/internal/auth"
+	authhandlers "modern-social-media/internal/handlers/auth"
 	"modern-social-media/internal/models"
 	"modern-social-media/internal/repository"
+	"modern-social-media/internal/services"
 	"modern-social-media/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // @name UserDTO
 type UserDTO struct {
-	ID             string    `json:"id"`
-	Username       string    `json:"username"`
-	Email          string    `json:"email"`
-	FirstName      string    `json:"first_name"`
-	LastName       string    `json:"last_name"`
-	Bio            string    `json:"bio"`
-	AvatarURL      string    `json:"avatar_url"`
-	IsVerified     bool      `json:"is_verified"`
-	IsActive       bool      `json:"is_active"`
-	Is2FAEnabled   bool      `json:"is_2fa_enabled"`
-	FollowersCount int64     `json:"followers_count"`
-	FollowingCount int64     `json:"following_count"`
-	CreatedAt      string    `json:"created_at"`
-	UpdatedAt      string    `json:"updated_at"`
+	ID              string `json:"id"`
+	Username        string `json:"username"`
+	Email           string `json:"email"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	Bio             string `json:"bio"`
+	AvatarURL       string `json:"avatar_url"`
+	CoverURL        string `json:"cover_url"`
+	IsVerified      bool   `json:"is_verified"`
+	IsActive        bool   `json:"is_active"`
+	Role            string `json:"role"`
+	Is2FAEnabled    bool   `json:"is_2fa_enabled"`
+	Email2FAEnabled bool   `json:"email_2fa_enabled"`
+	TOTPEnabled     bool   `json:"totp_enabled"`
+	FollowersCount  int64  `json:"followers_count"`
+	FollowingCount  int64  `json:"following_count"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
 }
 
 // @name CreateUserRequest
@@ -42,19 +51,18 @@ type CreateUserRequest struct {
 
 // @name UpdateUserRequest
 type UpdateUserRequest struct {
-	Username     *string `json:"username"`
-	Email        *string `json:"email"`
-	Password     *string `json:"password"`
-	FirstName    *string `json:"first_name"`
-	LastName     *string `json:"last_name"`
-	Bio          *string `json:"bio"`
-	AvatarURL    *string `json:"avatar_url"`
-	IsActive     *bool   `json:"is_active"`
-	IsVerified   *bool   `json:"is_verified"`
-	Is2FAEnabled *bool   `json:"is_2fa_enabled"`
+	Username        *string `json:"username"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	FirstName       *string `json:"first_name"`
+	LastName        *string `json:"last_name"`
+	Bio             *string `json:"bio"`
+	AvatarURL       *string `json:"avatar_url"`
+	IsActive        *bool   `json:"is_active"`
+	IsVerified      *bool   `json:"is_verified"`
+	Email2FAEnabled *bool   `json:"email_2fa_enabled"`
 }
 
-
 // @Summary Get all users
 // @Description Get a list of all users
 // @Tags users
@@ -74,20 +82,24 @@ func GetAllUsers(usersRepo repository.UserRepository) gin.HandlerFunc {
 			followersCount, _ := usersRepo.GetFollowersCount(c.Request.Context(), u.ID)
 			followingCount, _ := usersRepo.GetFollowingCount(c.Request.Context(), u.ID)
 			dtos = append(dtos, UserDTO{
-				ID:             u.ID,
-				Username:       u.Username,
-				Email:          u.Email,
-				FirstName:      u.FirstName,
-				LastName:       u.LastName,
-				Bio:            u.Bio,
-				AvatarURL:      u.AvatarURL,
-				IsVerified:     u.IsVerified,
-				IsActive:       u.IsActive,
-				Is2FAEnabled:   u.Is2FAEnabled,
-				FollowersCount: followersCount,
-				FollowingCount: followingCount,
-				CreatedAt:      u.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:      u.UpdatedAt.Format(time.RFC3339),
+				ID:              u.ID,
+				Username:        u.Username,
+				Email:           u.Email,
+				FirstName:       u.FirstName,
+				LastName:        u.LastName,
+				Bio:             u.Bio,
+				AvatarURL:       u.AvatarURL,
+				CoverURL:        u.CoverURL,
+				IsVerified:      u.IsVerified,
+				IsActive:        u.IsActive,
+				Role:            string(u.Role),
+				Is2FAEnabled:    u.Is2FAEnabled(),
+				Email2FAEnabled: u.Email2FAEnabled,
+				TOTPEnabled:     u.TOTPEnabled,
+				FollowersCount:  followersCount,
+				FollowingCount:  followingCount,
+				CreatedAt:       u.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:       u.UpdatedAt.Format(time.RFC3339),
 			})
 		}
 
@@ -120,20 +132,24 @@ func GetCurrentUser(usersRepo repository.UserRepository) gin.HandlerFunc {
 		followingCount, _ := usersRepo.GetFollowingCount(c.Request.Context(), userID)
 
 		dto := UserDTO{
-			ID:             user.ID,
-			Username:       user.Username,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			Bio:            user.Bio,
-			AvatarURL:      user.AvatarURL,
-			IsVerified:     user.IsVerified,
-			IsActive:       user.IsActive,
-			Is2FAEnabled:   user.Is2FAEnabled,
-			FollowersCount: followersCount,
-			FollowingCount: followingCount,
-			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
+			ID:              user.ID,
+			Username:        user.Username,
+			Email:           user.Email,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			Bio:             user.Bio,
+			AvatarURL:       user.AvatarURL,
+			CoverURL:        user.CoverURL,
+			IsVerified:      user.IsVerified,
+			IsActive:        user.IsActive,
+			Role:            string(user.Role),
+			Is2FAEnabled:    user.Is2FAEnabled(),
+			Email2FAEnabled: user.Email2FAEnabled,
+			TOTPEnabled:     user.TOTPEnabled,
+			FollowersCount:  followersCount,
+			FollowingCount:  followingCount,
+			CreatedAt:       user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:       user.UpdatedAt.Format(time.RFC3339),
 		}
 
 		c.JSON(http.StatusOK, dto)
@@ -158,65 +174,28 @@ func GetUserById(userRepo repository.UserRepository) gin.HandlerFunc {
 
 		followersCount, _ := userRepo.GetFollowersCount(c.Request.Context(), id)
 		followingCount, _ := userRepo.GetFollowingCount(c.Request.Context(), id)
-		
-		dto := UserDTO{
-			ID:             user.ID,
-			Username:       user.Username,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			Bio:            user.Bio,
-			AvatarURL:      user.AvatarURL,
-			IsVerified:     user.IsVerified,
-			IsActive:       user.IsActive,
-			Is2FAEnabled:   user.Is2FAEnabled,
-			FollowersCount: followersCount,
-			FollowingCount: followingCount,
-			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
-		}
-
-		c.JSON(http.StatusOK, dto)
-	}
-}
 
-// @Summary Get user by email
-// @Description Get user details by email
-// @Tags users
-// @Produce json
-// @Param email path string true "User Email"
-// @Success 200 {object} UserDTO
-// @Router /users/email/{email} [get]
-func GetUserByEmail(userRepo repository.UserRepository) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		email := c.Param("email")
-		user, err := userRepo.GetByEmail(c.Request.Context(), email)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User Not Found"})
-			return
-		}
-		// Since GetByEmail also returns *models.User, we should map it to DTO ideally,
-		// but let's assume for now keeping it consistent.
-		// NOTE: User model has passwords and stuff, DTO is safer.
-		followersCount, _ := userRepo.GetFollowersCount(c.Request.Context(), user.ID)
-		followingCount, _ := userRepo.GetFollowingCount(c.Request.Context(), user.ID)
-		
 		dto := UserDTO{
-			ID:             user.ID,
-			Username:       user.Username,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			Bio:            user.Bio,
-			AvatarURL:      user.AvatarURL,
-			IsVerified:     user.IsVerified,
-			IsActive:       user.IsActive,
-			Is2FAEnabled:   user.Is2FAEnabled,
-			FollowersCount: followersCount,
-			FollowingCount: followingCount,
-			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
+			ID:              user.ID,
+			Username:        user.Username,
+			Email:           user.Email,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			Bio:             user.Bio,
+			AvatarURL:       user.AvatarURL,
+			CoverURL:        user.CoverURL,
+			IsVerified:      user.IsVerified,
+			IsActive:        user.IsActive,
+			Role:            string(user.Role),
+			Is2FAEnabled:    user.Is2FAEnabled(),
+			Email2FAEnabled: user.Email2FAEnabled,
+			TOTPEnabled:     user.TOTPEnabled,
+			FollowersCount:  followersCount,
+			FollowingCount:  followingCount,
+			CreatedAt:       user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:       user.UpdatedAt.Format(time.RFC3339),
 		}
+
 		c.JSON(http.StatusOK, dto)
 	}
 }
@@ -256,20 +235,24 @@ func CreateUser(usersRepo repository.UserRepository) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		
+
 		dto := UserDTO{
-			ID:             user.ID,
-			Username:       user.Username,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			Bio:            user.Bio,
-			AvatarURL:      user.AvatarURL,
-			IsVerified:     user.IsVerified,
-			IsActive:       user.IsActive,
-			Is2FAEnabled:   user.Is2FAEnabled,
-			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
+			ID:              user.ID,
+			Username:        user.Username,
+			Email:           user.Email,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			Bio:             user.Bio,
+			AvatarURL:       user.AvatarURL,
+			CoverURL:        user.CoverURL,
+			IsVerified:      user.IsVerified,
+			IsActive:        user.IsActive,
+			Role:            string(user.Role),
+			Is2FAEnabled:    user.Is2FAEnabled(),
+			Email2FAEnabled: user.Email2FAEnabled,
+			TOTPEnabled:     user.TOTPEnabled,
+			CreatedAt:       user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:       user.UpdatedAt.Format(time.RFC3339),
 		}
 
 		c.JSON(http.StatusCreated, dto)
@@ -316,44 +299,245 @@ func UpdateUser(usersRepo repository.UserRepository) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		
+
 		followersCount, _ := usersRepo.GetFollowersCount(c.Request.Context(), existing.ID)
 		followingCount, _ := usersRepo.GetFollowingCount(c.Request.Context(), existing.ID)
 
 		dto := UserDTO{
-			ID:             existing.ID,
-			Username:       existing.Username,
-			Email:          existing.Email,
-			FirstName:      existing.FirstName,
-			LastName:       existing.LastName,
-			Bio:            existing.Bio,
-			AvatarURL:      existing.AvatarURL,
-			IsVerified:     existing.IsVerified,
-			IsActive:       existing.IsActive,
-			Is2FAEnabled:   existing.Is2FAEnabled,
-			FollowersCount: followersCount,
-			FollowingCount: followingCount,
-			CreatedAt:      existing.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      existing.UpdatedAt.Format(time.RFC3339),
+			ID:              existing.ID,
+			Username:        existing.Username,
+			Email:           existing.Email,
+			FirstName:       existing.FirstName,
+			LastName:        existing.LastName,
+			Bio:             existing.Bio,
+			AvatarURL:       existing.AvatarURL,
+			CoverURL:        existing.CoverURL,
+			IsVerified:      existing.IsVerified,
+			IsActive:        existing.IsActive,
+			Role:            string(existing.Role),
+			Is2FAEnabled:    existing.Is2FAEnabled(),
+			Email2FAEnabled: existing.Email2FAEnabled,
+			TOTPEnabled:     existing.TOTPEnabled,
+			FollowersCount:  followersCount,
+			FollowingCount:  followingCount,
+			CreatedAt:       existing.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:       existing.UpdatedAt.Format(time.RFC3339),
 		}
 
 		c.JSON(http.StatusOK, dto)
 	}
 }
 
-func DeleteUser(userRepo repository.UserRepository) gin.HandlerFunc {
+// DeleteUser skips the grace period: the account is deactivated right away
+// and the purge job removes its data on the next run.
+func DeleteUser(userRepo repository.UserRepository, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		if err := userRepo.Delete(c.Request.Context(), id); err != nil {
+		if _, err := userRepo.GetByID(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
+		if err := userRepo.ScheduleDeletion(c.Request.Context(), id, time.Now()); err != nil {
+			if err.Error() == "last_admin" {
+				c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last admin"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+			return
+		}
+		if err := sessions.RevokeAllForUser(c.Request.Context(), id, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user sessions"})
+			return
+		}
+		if err := refreshTokens.RevokeAllForUser(c.Request.Context(), id, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user sessions"})
+			return
+		}
 
 		c.Status(http.StatusNoContent)
 	}
 }
 
+// @name UpdateCurrentUserRequest
+type UpdateCurrentUserRequest struct {
+	FirstName *string `json:"first_name" binding:"omitempty,max=50"`
+	LastName  *string `json:"last_name" binding:"omitempty,max=50"`
+	Bio       *string `json:"bio" binding:"omitempty,max=500"`
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,max=255"`
+}
+
+// @name ChangePasswordRequest
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=128"`
+}
+
+// @Summary Update current user
+// @Description Update profile fields of the authenticated user. Only first_name, last_name, bio and avatar_url can be changed here.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body UpdateCurrentUserRequest true "Profile fields"
+// @Success 200 {object} UserDTO
+// @Security BearerAuth
+// @Router /user/me [patch]
+func UpdateCurrentUser(usersRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateCurrentUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		user, err := usersRepo.GetByID(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User Not Found"})
+			return
+		}
+
+		if req.FirstName != nil {
+			user.FirstName = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			user.LastName = strings.TrimSpace(*req.LastName)
+		}
+		if req.Bio != nil {
+			user.Bio = strings.TrimSpace(*req.Bio)
+		}
+		if req.AvatarURL != nil {
+			avatarURL := strings.TrimSpace(*req.AvatarURL)
+			if !utils.IsValidMediaURL(avatarURL) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid avatar_url"})
+				return
+			}
+			user.AvatarURL = avatarURL
+		}
+
+		if err := usersRepo.UpdateUser(c.Request.Context(), user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, buildUserDTO(c, usersRepo, user))
+	}
+}
+
+// @Summary Change password
+// @Description Change the password of the authenticated user. All other sessions are signed out.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Passwords"
+// @Success 200 {object} UserDTO
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /user/me/password [post]
+func ChangeCurrentUserPassword(svc services.AuthService, usersRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "new password must be 8-128 characters"})
+			return
+		}
+
+		meta := services.ClientMeta{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+		user, err := svc.ChangePassword(c.Request.Context(), c.GetString("userID"), c.GetString("sessionID"), req.CurrentPassword, req.NewPassword, meta)
+		if err != nil {
+			var rl *services.RateLimitError
+			switch {
+			case errors.As(err, &rl):
+				authhandlers.RespondTooManyAttempts(c, err)
+			case err.Error() == "weak_password":
+				c.JSON(http.StatusBadRequest, gin.H{"error": "new password must be 8-128 characters"})
+			case err.Error() == "not_found":
+				c.JSON(http.StatusNotFound, gin.H{"error": "User Not Found"})
+			case err.Error() == "invalid_credentials":
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid current password"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, buildUserDTO(c, usersRepo, user))
+	}
+}
+
+func buildUserDTO(c *gin.Context, usersRepo repository.UserRepository, user *models.User) UserDTO {
+	followersCount, _ := usersRepo.GetFollowersCount(c.Request.Context(), user.ID)
+	followingCount, _ := usersRepo.GetFollowingCount(c.Request.Context(), user.ID)
+
+	return UserDTO{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Bio:             user.Bio,
+		AvatarURL:       user.AvatarURL,
+		CoverURL:        user.CoverURL,
+		IsVerified:      user.IsVerified,
+		IsActive:        user.IsActive,
+		Role:            string(user.Role),
+		Is2FAEnabled:    user.Is2FAEnabled(),
+		Email2FAEnabled: user.Email2FAEnabled,
+		TOTPEnabled:     user.TOTPEnabled,
+		FollowersCount:  followersCount,
+		FollowingCount:  followingCount,
+		CreatedAt:       user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       user.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// @name SetUserRoleRequest
+type SetUserRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// @Summary Set user role
+// @Description Grant or revoke a role. Every change is written to the role audit log.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body SetUserRoleRequest true "Role"
+// @Success 200 {object} UserDTO
+// @Security BearerAuth
+// @Router /user/{id}/role [put]
+func SetUserRole(usersRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SetUserRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		role := models.Role(req.Role)
+		if !role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+			return
+		}
+
+		actorID := c.GetString("userID")
+		user, err := usersRepo.SetRole(c.Request.Context(), c.Param("id"), role, &actorID, req.Reason)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case err.Error() == "last_admin":
+				c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, buildUserDTO(c, usersRepo, user))
+	}
+}
+
 func applyUserPatch(existing *models.User, patch interface{}) {
 	rv := reflect.ValueOf(patch)
 	if rv.Kind() != reflect.Struct {
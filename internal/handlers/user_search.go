@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @name UserSearchItem
+type userSearchItem struct {
+	ID          string  `json:"id"`
+	Username    string  `json:"username"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	AvatarURL   string  `json:"avatar_url"`
+	IsVerified  bool    `json:"is_verified"`
+	IsFollowing bool    `json:"is_following"`
+	FollowsYou  bool    `json:"follows_you"`
+	Score       float64 `json:"score"`
+}
+
+// @name UserSearchResponse
+type userSearchResponse struct {
+	Users      []userSearchItem `json:"users"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// @Summary Search users
+// @Description Fuzzy search by username and name, ranked by similarity. People you follow or who follow you rank higher.
+// @Tags users
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} userSearchResponse
+// @Router /user/search [get]
+func SearchUsers(usersRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" || utf8.RuneCountInString(q) > 64 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q must be 1-64 characters"})
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 50 {
+			limit = 20
+		}
+
+		var after *repository.UserSearchCursor
+		if raw := c.Query("cursor"); raw != "" {
+			after = &repository.UserSearchCursor{}
+			if err := utils.DecodeCursor(raw, after); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		results, err := usersRepo.Search(c.Request.Context(), c.GetString("userID"), q, after, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search users"})
+			return
+		}
+
+		resp := userSearchResponse{Users: make([]userSearchItem, 0, limit)}
+		if len(results) > limit {
+			results = results[:limit]
+			last := results[len(results)-1]
+			resp.NextCursor = utils.EncodeCursor(repository.UserSearchCursor{Score: last.Score, ID: last.ID})
+		}
+		for _, r := range results {
+			resp.Users = append(resp.Users, userSearchItem{
+				ID:          r.ID,
+				Username:    r.Username,
+				FirstName:   r.FirstName,
+				LastName:    r.LastName,
+				AvatarURL:   r.AvatarURL,
+				IsVerified:  r.IsVerified,
+				IsFollowing: r.IsFollowing,
+				FollowsYou:  r.FollowsYou,
+				Score:       r.Score,
+			})
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
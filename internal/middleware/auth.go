@@ -1,79 +1,96 @@
 package middleware
 
 import (
-	"errors"
+	"context"
 	"net/http"
+	"slices"
 	"strings"
 
 	"modern-social-media/internal/auth"
+	"modern-social-media/internal/models"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func Auth(jwtSecret string) gin.HandlerFunc {
+type SessionChecker interface {
+	IsActive(ctx context.Context, id, userID string) (bool, error)
+}
+
+func Auth(keys *auth.KeyManager, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractBearerToken(c)
 		if token == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization token"})
 			return
 		}
-
-		claims, err := validateAccessToken(token, jwtSecret)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
+		if authenticate(c, token, keys, sessions) {
+			c.Next()
 		}
-
-		c.Set("userID", claims.UserID)
-		c.Set("isAdmin", claims.IsAdmin)
-		c.Next()
 	}
 }
 
-func extractBearerToken(c *gin.Context) string {
-	authz := c.GetHeader("Authorization")
-	if !strings.HasPrefix(authz, "Bearer ") {
-		return ""
+// OptionalAuth identifies the caller when a bearer token is present and lets
+// anonymous requests through. A token that is present but invalid is still
+// rejected so clients know to refresh it.
+func OptionalAuth(keys *auth.KeyManager, sessions SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := extractBearerToken(c)
+		if token == "" {
+			c.Next()
+			return
+		}
+		if authenticate(c, token, keys, sessions) {
+			c.Next()
+		}
 	}
-	return strings.TrimPrefix(authz, "Bearer ")
 }
 
-func validateAccessToken(tokenStr, secret string) (*auth.AccessClaims, error) {
-	var claims auth.AccessClaims
-
-	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-
+func authenticate(c *gin.Context, token string, keys *auth.KeyManager, sessions SessionChecker) bool {
+	claims, err := keys.ParseAccess(token)
 	if err != nil {
-		return nil, errors.New("invalid token")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	if claims.SessionID == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+		return false
 	}
-
-	if err := claims.Validate(); err != nil {
-		return nil, err
+	active, err := sessions.IsActive(c.Request.Context(), claims.SessionID, claims.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check session"})
+		return false
+	}
+	if !active {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+		return false
 	}
 
-	return &claims, nil
+	c.Set("userID", claims.UserID)
+	c.Set("sessionID", claims.SessionID)
+	c.Set("role", claims.Role)
+	c.Set("permissions", claims.Permissions)
+	return true
 }
 
-func StaticToken(required string) gin.HandlerFunc {
+func extractBearerToken(c *gin.Context) string {
+	authz := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authz, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(authz, "Bearer ")
+}
+
+// RequirePermission must be chained after Auth; it only trusts the
+// permissions embedded in the access token.
+func RequirePermission(perms ...models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if required == "" {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "forbidden"})
-			return
-		}
-		token := c.GetHeader("X-Admin-Token")
-		if token == "" || token != required {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
-			return
+		granted := c.GetStringSlice("permissions")
+		for _, p := range perms {
+			if !slices.Contains(granted, string(p)) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+				return
+			}
 		}
 		c.Next()
 	}
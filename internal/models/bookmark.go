@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// Collection is a named folder for a user's bookmarks. Names are unique per
+// user.
+type Collection struct {
+	ID        string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_collections_user_name,priority:1" json:"-"`
+	Name      string    `gorm:"size:100;not null;uniqueIndex:idx_collections_user_name,priority:2" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *Collection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = cuid.New()
+	}
+	return nil
+}
+
+// Bookmark privately saves a post for a user. CollectionID is nil for
+// bookmarks that aren't filed in any collection.
+type Bookmark struct {
+	ID           string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID       string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_bookmarks_user_post,priority:1" json:"-"`
+	PostID       string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_bookmarks_user_post,priority:2;index" json:"post_id"`
+	CollectionID *string   `gorm:"type:varchar(25);index" json:"collection_id"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+
+	Collection *Collection `gorm:"foreignKey:CollectionID;constraint:OnDelete:SET NULL" json:"-"`
+}
+
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = cuid.New()
+	}
+	return nil
+}
@@ -44,6 +44,8 @@ type Message struct {
 	SenderID       string    `gorm:"type:varchar(25);index;not null" json:"sender_id"`
 	Body           string    `gorm:"type:text;not null" json:"body"`
 	CreatedAt      time.Time `json:"created_at"`
+
+	Mentions []Mention `gorm:"polymorphic:Source;polymorphicValue:message" json:"mentions"`
 }
 
 func (m *Message) BeforeCreate(tx *gorm.DB) error {
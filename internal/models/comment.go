@@ -15,9 +15,11 @@ type Comment struct {
 	ImageURL  string `gorm:"size:255" json:"image_url,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Post Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	User     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Post     Post      `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Mentions []Mention `gorm:"polymorphic:Source;polymorphicValue:comment" json:"mentions"`
 }
 
 
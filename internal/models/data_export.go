@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+	DataExportExpired    DataExportStatus = "expired"
+)
+
+type DataExport struct {
+	ID          string           `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID      string           `gorm:"type:varchar(25);index;not null" json:"user_id"`
+	Status      DataExportStatus `gorm:"type:varchar(20);index;not null;default:pending" json:"status"`
+	FilePath    string           `gorm:"size:255" json:"-"`
+	SizeBytes   int64            `json:"size_bytes"`
+	TokenHash   string           `gorm:"size:64;index" json:"-"`
+	Error       string           `gorm:"size:255" json:"-"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = cuid.New()
+	}
+	return nil
+}
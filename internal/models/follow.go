@@ -13,6 +13,10 @@ type Follow struct {
 	FollowingID string `gorm:"type:varchar(25);not null" json:"following_id"`
 	CreatedAt   time.Time `json:"created_at"`
 
+	// CloseFriend is set by the followed user to share close-friends posts
+	// with this follower. It goes away with the follow.
+	CloseFriend bool `gorm:"not null;default:false" json:"close_friend"`
+
 	Follower  User `gorm:"foreignKey:FollowerID" json:"follower,omitempty"`
 	Following User `gorm:"foreignKey:FollowingID" json:"following,omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// Hashtag is a normalized (lowercased, without '#') tag name.
+type Hashtag struct {
+	ID        string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	Name      string    `gorm:"size:64;not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *Hashtag) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = cuid.New()
+	}
+	return nil
+}
+
+// PostHashtag links a post to a tag found in its content. CreatedAt is when
+// the tag first appeared on the post, which is what trending counts.
+type PostHashtag struct {
+	PostID    string    `gorm:"type:varchar(25);primaryKey" json:"post_id"`
+	HashtagID string    `gorm:"type:varchar(25);primaryKey;index" json:"hashtag_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TrendingHashtag is one row of the latest trending snapshot. The table is
+// rebuilt by the scheduled refresh, never on request.
+type TrendingHashtag struct {
+	Rank       int       `gorm:"primaryKey;autoIncrement:false" json:"rank"`
+	Name       string    `gorm:"size:64;not null" json:"name"`
+	Uses       int       `gorm:"not null" json:"uses"`
+	Authors    int       `gorm:"not null" json:"authors"`
+	Score      float64   `gorm:"not null" json:"score"`
+	ComputedAt time.Time `gorm:"not null" json:"computed_at"`
+}
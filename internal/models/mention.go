@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+const (
+	MentionSourcePost    = "post"
+	MentionSourceComment = "comment"
+	MentionSourceMessage = "message"
+)
+
+// Mention is a resolved "@username" in a post, comment or chat message.
+// Offset and Length are in Unicode code points and include the '@'.
+type Mention struct {
+	ID         string    `gorm:"type:varchar(25);primaryKey" json:"-"`
+	SourceType string    `gorm:"type:varchar(20);not null;index:idx_mentions_source,priority:1" json:"-"`
+	SourceID   string    `gorm:"type:varchar(25);not null;index:idx_mentions_source,priority:2" json:"-"`
+	AuthorID   string    `gorm:"type:varchar(25);not null;index" json:"-"`
+	UserID     string    `gorm:"type:varchar(25);not null;index" json:"user_id"`
+	Username   string    `gorm:"size:50;not null" json:"username"`
+	Offset     int       `gorm:"not null" json:"offset"`
+	Length     int       `gorm:"not null" json:"length"`
+	CreatedAt  time.Time `json:"-"`
+}
+
+func (m *Mention) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = cuid.New()
+	}
+	return nil
+}
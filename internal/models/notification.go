@@ -14,6 +14,10 @@ const (
 	NotificationTypeLike    NotificationType = "like"
 	NotificationTypeComment NotificationType = "comment"
 	NotificationTypeMention NotificationType = "mention"
+	NotificationTypeRepost  NotificationType = "repost"
+	NotificationTypeQuote   NotificationType = "quote"
+
+	NotificationTypeDataExport NotificationType = "data_export"
 )
 
 type Notification struct {
@@ -26,6 +30,10 @@ type Notification struct {
 	CreatedAt time.Time        `gorm:"index" json:"created_at"`
 	UpdatedAt time.Time        `json:"updated_at"`
 
+	// TargetType tells what TargetID points at when the notification type
+	// alone doesn't, e.g. a mention in a post, comment or message.
+	TargetType string `gorm:"type:varchar(20)" json:"target_type,omitempty"`
+
 	User  User `gorm:"foreignKey:UserID" json:"-"`
 	Actor User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
 }
@@ -7,6 +7,24 @@ import (
 	"gorm.io/gorm"
 )
 
+// PostAudience decides who besides the author can see a post.
+type PostAudience string
+
+const (
+	PostAudiencePublic       PostAudience = "public"
+	PostAudienceFollowers    PostAudience = "followers"
+	PostAudienceCloseFriends PostAudience = "close_friends"
+	PostAudiencePrivate      PostAudience = "private"
+)
+
+func (a PostAudience) Valid() bool {
+	switch a {
+	case PostAudiencePublic, PostAudienceFollowers, PostAudienceCloseFriends, PostAudiencePrivate:
+		return true
+	}
+	return false
+}
+
 type Post struct {
 	ID            string `gorm:"type:varchar(25);primaryKey" json:"id"`
 	UserID        string `gorm:"type:varchar(25);not null" json:"user_id"`
@@ -17,9 +35,24 @@ type Post struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
-	User     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Likes    []Like    `gorm:"foreignKey:PostID" json:"likes,omitempty"`
-	Comments []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	EditedAt      *time.Time     `json:"edited_at"`
+	RevisionCount int            `gorm:"not null;default:0" json:"revision_count"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// QuotedPostID is set on quote posts. There is deliberately no foreign
+	// key: once the original is purged the quote keeps the ID and renders a
+	// tombstone.
+	QuotedPostID *string `gorm:"type:varchar(25);index" json:"quoted_post_id"`
+	QuotedPost   *Post   `gorm:"foreignKey:QuotedPostID;-:migration" json:"-"`
+	RepostsCount int     `gorm:"not null;default:0" json:"reposts_count"`
+
+	Audience PostAudience `gorm:"size:20;not null;default:public;index" json:"audience"`
+
+	User     User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Media    []PostMedia `gorm:"foreignKey:PostID" json:"media"`
+	Mentions []Mention   `gorm:"polymorphic:Source;polymorphicValue:post" json:"mentions"`
+	Likes    []Like      `gorm:"foreignKey:PostID" json:"likes,omitempty"`
+	Comments []Comment   `gorm:"foreignKey:PostID" json:"comments,omitempty"`
 }
 
 
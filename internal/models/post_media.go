@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type MediaType string
+
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVideo MediaType = "video"
+)
+
+type PostMedia struct {
+	ID        string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	PostID    string    `gorm:"type:varchar(25);not null;index:idx_post_media_post_position" json:"-"`
+	Position  int       `gorm:"not null;default:0;index:idx_post_media_post_position" json:"position"`
+	URL       string    `gorm:"size:255;not null" json:"url"`
+	Type      MediaType `gorm:"type:varchar(10);not null;default:'image'" json:"type"`
+	MimeType  string    `gorm:"size:50;not null" json:"mime_type"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	SizeBytes int64     `json:"size_bytes"`
+	AltText   string    `gorm:"size:1000" json:"alt_text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m *PostMedia) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = cuid.New()
+	}
+	return nil
+}
+
+func (PostMedia) TableName() string {
+	return "post_media"
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// PostRevision is a version of a post that was later edited. Number counts
+// from 1 for the original text. Media is the attachment list the version was
+// published with, so edits that only touch attachments are recorded too.
+type PostRevision struct {
+	ID          string        `gorm:"type:varchar(25);primaryKey" json:"id"`
+	PostID      string        `gorm:"type:varchar(25);not null;uniqueIndex:idx_post_revisions_post_number" json:"post_id"`
+	Number      int           `gorm:"not null;uniqueIndex:idx_post_revisions_post_number" json:"number"`
+	Content     string        `gorm:"type:text;not null" json:"content"`
+	ImageURL    string        `gorm:"size:255" json:"image_url"`
+	Media       RevisionMedia `gorm:"type:jsonb;not null;default:'[]'" json:"media"`
+	PublishedAt time.Time     `json:"published_at"`
+	CreatedAt   time.Time     `json:"replaced_at"`
+}
+
+func (r *PostRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = cuid.New()
+	}
+	return nil
+}
+
+// RevisionMedia is a snapshot of a post's attachments. Attachment rows are
+// deleted when an edit removes them, so a revision keeps its own copy.
+type RevisionMedia []RevisionAttachment
+
+type RevisionAttachment struct {
+	URL     string    `json:"url"`
+	Type    MediaType `json:"type"`
+	AltText string    `json:"alt_text"`
+}
+
+func (m RevisionMedia) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *RevisionMedia) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = RevisionMedia{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return fmt.Errorf("unsupported revision media type %T", src)
+}
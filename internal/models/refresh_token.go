@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type RefreshToken struct {
+	ID        string     `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID    string     `gorm:"type:varchar(25);index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"type:varchar(25);index;not null" json:"family_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = cuid.New()
+	}
+	return nil
+}
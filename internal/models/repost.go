@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// Repost shares someone's post unchanged with the reposter's followers.
+// Quote posts are regular posts with QuotedPostID set.
+type Repost struct {
+	ID        string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_reposts_user_post,priority:1" json:"user_id"`
+	PostID    string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_reposts_user_post,priority:2;index" json:"post_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (r *Repost) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermUsersRead       Permission = "users:read"
+	PermUsersWrite      Permission = "users:write"
+	PermUsersDelete     Permission = "users:delete"
+	PermRolesManage     Permission = "roles:manage"
+	PermContentModerate Permission = "content:moderate"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PermUsersRead, PermContentModerate},
+	RoleAdmin:     {PermUsersRead, PermUsersWrite, PermUsersDelete, PermRolesManage, PermContentModerate},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// RoleChange is the audit trail for every role grant or revocation.
+// ActorID is nil when the change was made outside the API (bootstrap).
+type RoleChange struct {
+	ID        string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(25);not null;index" json:"user_id"`
+	ActorID   *string   `gorm:"type:varchar(25)" json:"actor_id,omitempty"`
+	OldRole   Role      `gorm:"size:20;not null" json:"old_role"`
+	NewRole   Role      `gorm:"size:20;not null" json:"new_role"`
+	Reason    string    `gorm:"size:255" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r *RoleChange) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = cuid.New()
+	}
+	return nil
+}
@@ -8,13 +8,14 @@ import (
 )
 
 type Story struct {
-	ID         string    `gorm:"type:varchar(25);primaryKey" json:"id"`
-	UserID     string    `gorm:"type:varchar(25);not null" json:"user_id"`
-	MediaURL   string    `gorm:"size:255;not null" json:"media_url"`
-	MediaType  string    `gorm:"size:20;not null;default:'image'" json:"media_type"`
-	LikesCount int       `gorm:"default:0" json:"likes_count"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string         `gorm:"type:varchar(25);primaryKey" json:"id"`
+	UserID     string         `gorm:"type:varchar(25);not null" json:"user_id"`
+	MediaURL   string         `gorm:"size:255;not null" json:"media_url"`
+	MediaType  string         `gorm:"size:20;not null;default:'image'" json:"media_type"`
+	LikesCount int            `gorm:"default:0" json:"likes_count"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
 	User  User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Likes []Like `gorm:"foreignKey:StoryID;constraint:OnDelete:CASCADE" json:"likes,omitempty"`
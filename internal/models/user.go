@@ -8,32 +8,53 @@ import (
 )
 
 type User struct {
-	ID           string    `gorm:"type:varchar(25);primaryKey" json:"id"`
-	Username     string    `gorm:"uniqueIndex;not null;size:50" json:"username"`
-	Email        string    `gorm:"uniqueIndex;not null;size:100" json:"email"`
-	Password     string    `gorm:"not null;size:255" json:"-"`
-	FirstName    string    `gorm:"size:50" json:"first_name"`
-	LastName     string    `gorm:"size:50" json:"last_name"`
-	Bio          string    `gorm:"type:text" json:"bio"`
-	AvatarURL    string    `gorm:"size:255" json:"avatar_url"`
-	IsVerified   bool      `gorm:"default:false" json:"is_verified"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	Is2FAEnabled bool      `gorm:"default:false" json:"is_2fa_enabled"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              string    `gorm:"type:varchar(25);primaryKey" json:"id"`
+	Username        string    `gorm:"uniqueIndex;not null;size:50" json:"username"`
+	Email           string    `gorm:"uniqueIndex;not null;size:100" json:"email"`
+	Password        string    `gorm:"not null;size:255" json:"-"`
+	FirstName       string    `gorm:"size:50" json:"first_name"`
+	LastName        string    `gorm:"size:50" json:"last_name"`
+	Bio             string    `gorm:"type:text" json:"bio"`
+	AvatarURL       string    `gorm:"size:255" json:"avatar_url"`
+	CoverURL        string    `gorm:"size:255" json:"cover_url"`
+	IsVerified      bool      `gorm:"default:false" json:"is_verified"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	Role            Role      `gorm:"size:20;not null;default:user" json:"role"`
+	Email2FAEnabled bool      `gorm:"column:is_2fa_enabled;default:false" json:"email_2fa_enabled"`
+	TOTPEnabled     bool      `gorm:"column:totp_enabled;default:false" json:"totp_enabled"`
+	TOTPSecret      string    `gorm:"column:totp_secret;size:64" json:"-"`
+	TOTPLastCounter int64     `gorm:"column:totp_last_counter;default:0" json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty"`
+	PurgedAt            *time.Time `json:"-"`
 
 	FollowersCount int64 `gorm:"-" json:"followers_count,omitempty"`
 	FollowingCount int64 `gorm:"-" json:"following_count,omitempty"`
 
-	Posts     []Post    `gorm:"foreignKey:UserID" json:"posts,omitempty"`
-	Likes     []Like    `gorm:"foreignKey:UserID" json:"likes,omitempty"`
-	Comments  []Comment `gorm:"foreignKey:UserID" json:"comments,omitempty"`
-	Followers []Follow  `gorm:"foreignKey:FollowingID" json:"followers,omitempty"`
-	Following []Follow  `gorm:"foreignKey:FollowerID" json:"following,omitempty"`
-	Stories   []Story   `gorm:"foreignKey:UserID" json:"stories"`
+	Posts         []Post         `gorm:"foreignKey:UserID" json:"posts,omitempty"`
+	Likes         []Like         `gorm:"foreignKey:UserID" json:"likes,omitempty"`
+	Comments      []Comment      `gorm:"foreignKey:UserID" json:"comments,omitempty"`
+	Followers     []Follow       `gorm:"foreignKey:FollowingID" json:"followers,omitempty"`
+	Following     []Follow       `gorm:"foreignKey:FollowerID" json:"following,omitempty"`
+	Stories       []Story        `gorm:"foreignKey:UserID" json:"stories"`
 	Notifications []Notification `gorm:"foreignKey:UserID" json:"notifications,omitempty"`
 }
 
+func (u *User) Is2FAEnabled() bool {
+	return u.Email2FAEnabled || u.TOTPEnabled
+}
+
+func (u *User) HasPermission(p Permission) bool {
+	for _, granted := range u.Role.Permissions() {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
 		u.ID = cuid.New()
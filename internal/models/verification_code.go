@@ -12,8 +12,10 @@ type VerificationCode struct {
 	UserID     string     `gorm:"type:varchar(25);index;not null" json:"user_id"`
 	Purpose    string     `gorm:"size:50;index;not null" json:"purpose"`
 	Code       string     `gorm:"size:10;not null" json:"code"`
+	Target     string     `gorm:"size:100" json:"-"`
 	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"`
 	ConsumedAt *time.Time `json:"consumed_at"`
+	Attempts   int        `gorm:"default:0" json:"attempts"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleDeletion deactivates the user and marks them for purge at at. Like
+// SetRole it refuses to remove the only remaining active admin.
+func (r UserRepository) ScheduleDeletion(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if user.Role == models.RoleAdmin {
+			var adminIDs []string
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.User{}).
+				Where("role = ? AND is_active AND id <> ?", models.RoleAdmin, id).Pluck("id", &adminIDs).Error; err != nil {
+				return err
+			}
+			if len(adminIDs) == 0 {
+				return errors.New("last_admin")
+			}
+		}
+		return tx.Model(&user).
+			Where("purged_at IS NULL").
+			Updates(map[string]interface{}{"is_active": false, "deletion_scheduled_at": at}).Error
+	})
+}
+
+func (r UserRepository) CancelDeletion(ctx context.Context, id string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at > NOW() AND purged_at IS NULL", id).
+		Updates(map[string]interface{}{"is_active": true, "deletion_scheduled_at": nil})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r UserRepository) ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("deletion_scheduled_at <= ? AND purged_at IS NULL AND is_active = false", now).
+		Order("deletion_scheduled_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Purge removes everything the user created and anonymizes the users row in
+// place. The row itself is kept as a tombstone so conversations on the other
+// side still resolve to a (deleted) sender; the message bodies are blanked. Denormalized
+// counters on other people's posts and stories are recomputed from the
+// remaining rows. It returns upload URLs that are no longer referenced by any
+// row and can be removed from disk.
+func (r UserRepository) Purge(ctx context.Context, userID string) ([]string, error) {
+	var orphaned []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ownPosts := tx.Unscoped().Model(&models.Post{}).Select("id").Where("user_id = ?", userID)
+		ownStories := tx.Unscoped().Model(&models.Story{}).Select("id").Where("user_id = ?", userID)
+		commentsOnOwnPosts := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id IN (?)", ownPosts)
+
+		var touchedPosts, touchedStories []string
+		if err := tx.Raw(`SELECT post_id FROM likes WHERE user_id = ? AND post_id IS NOT NULL
+			UNION SELECT post_id FROM comments WHERE user_id = ?
+			UNION SELECT post_id FROM reposts WHERE user_id = ?`, userID, userID, userID).Scan(&touchedPosts).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Like{}).Where("user_id = ? AND story_id IS NOT NULL", userID).
+			Distinct().Pluck("story_id", &touchedStories).Error; err != nil {
+			return err
+		}
+
+		var media []string
+		if err := tx.Raw(`SELECT image_url FROM posts WHERE user_id = ? AND image_url <> ''
+			UNION SELECT image_url FROM comments WHERE (user_id = ? OR post_id IN (SELECT id FROM posts WHERE user_id = ?)) AND image_url <> ''
+			UNION SELECT media_url FROM stories WHERE user_id = ?
+			UNION SELECT url FROM post_media WHERE post_id IN (SELECT id FROM posts WHERE user_id = ?)
+			UNION SELECT image_url FROM post_revisions WHERE post_id IN (SELECT id FROM posts WHERE user_id = ?) AND image_url <> ''
+			UNION SELECT m->>'url' FROM post_revisions r, jsonb_array_elements(r.media) m WHERE r.post_id IN (SELECT id FROM posts WHERE user_id = ?)`,
+			userID, userID, userID, userID, userID, userID, userID).Scan(&media).Error; err != nil {
+			return err
+		}
+
+		deletes := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.Mention{}, "author_id = ? OR user_id = ? OR (source_type = 'comment' AND source_id IN (?))", []interface{}{userID, userID, commentsOnOwnPosts}},
+			{&models.PostMedia{}, "post_id IN (?)", []interface{}{ownPosts}},
+			{&models.PostRevision{}, "post_id IN (?)", []interface{}{ownPosts}},
+			{&models.PostHashtag{}, "post_id IN (?)", []interface{}{ownPosts}},
+			{&models.Like{}, "user_id = ? OR post_id IN (?) OR story_id IN (?)", []interface{}{userID, ownPosts, ownStories}},
+			{&models.Repost{}, "user_id = ? OR post_id IN (?)", []interface{}{userID, ownPosts}},
+			{&models.Bookmark{}, "user_id = ? OR post_id IN (?)", []interface{}{userID, ownPosts}},
+			{&models.Collection{}, "user_id = ?", []interface{}{userID}},
+			{&models.Comment{}, "user_id = ? OR post_id IN (?)", []interface{}{userID, ownPosts}},
+			{&models.Post{}, "user_id = ?", []interface{}{userID}},
+			{&models.Story{}, "user_id = ?", []interface{}{userID}},
+			{&models.Follow{}, "follower_id = ? OR following_id = ?", []interface{}{userID, userID}},
+			{&models.Notification{}, "user_id = ? OR actor_id = ?", []interface{}{userID, userID}},
+			{&models.Skill{}, "user_id = ?", []interface{}{userID}},
+			{&models.Session{}, "user_id = ?", []interface{}{userID}},
+			{&models.RefreshToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.RecoveryCode{}, "user_id = ?", []interface{}{userID}},
+			{&models.VerificationCode{}, "user_id = ?", []interface{}{userID}},
+			{&models.DataExport{}, "user_id = ?", []interface{}{userID}},
+		}
+		for _, d := range deletes {
+			if err := tx.Unscoped().Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(touchedPosts) > 0 {
+			if err := tx.Exec(`UPDATE posts p SET
+				likes_count = (SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id),
+				comments_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL),
+				reposts_count = (SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id)
+				WHERE p.id IN ?`, touchedPosts).Error; err != nil {
+				return err
+			}
+		}
+		if len(touchedStories) > 0 {
+			if err := tx.Exec(`UPDATE stories s SET likes_count = (SELECT COUNT(*) FROM likes l WHERE l.story_id = s.id)
+				WHERE s.id IN ?`, touchedStories).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Message{}).Where("sender_id = ?", userID).Update("body", "").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE users SET
+			username = 'deleted_' || id,
+			email = 'deleted-' || id || '@deleted.invalid',
+			password = '', first_name = '', last_name = '', bio = '',
+			avatar_url = '', cover_url = '',
+			is_verified = false, is_active = false, role = ?,
+			is_2fa_enabled = false, totp_enabled = false, totp_secret = '', totp_last_counter = 0,
+			purged_at = NOW()
+			WHERE id = ?`, models.RoleUser, userID).Error; err != nil {
+			return err
+		}
+
+		var err error
+		orphaned, err = unreferencedUploads(tx, media)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+// purgeableUploads are the upload directories whose files belong to a single
+// post or story. Older posts could store any image_url, so a URL found on a
+// post may point at an avatar, a cover or a default image; those are never
+// removed here.
+var purgeableUploads = []string{"/uploads/posts/", "/uploads/stories/"}
+
+// unreferencedUploads filters urls down to post and story uploads that no
+// post, attachment, revision, comment, story, avatar or cover points at. Seeded posts
+// share image files, so a file can only go once its last reference is gone.
+func unreferencedUploads(tx *gorm.DB, urls []string) ([]string, error) {
+	var orphaned []string
+	seen := map[string]bool{}
+	for _, url := range urls {
+		if url == "" || seen[url] || strings.Contains(url, "..") {
+			continue
+		}
+		seen[url] = true
+		if !slices.ContainsFunc(purgeableUploads, func(prefix string) bool { return strings.HasPrefix(url, prefix) }) {
+			continue
+		}
+		var refs int64
+		if err := tx.Raw(`SELECT
+			(SELECT COUNT(*) FROM posts WHERE image_url = @url) +
+			(SELECT COUNT(*) FROM post_media WHERE url = @url) +
+			(SELECT COUNT(*) FROM post_revisions WHERE image_url = @url OR media @> jsonb_build_array(jsonb_build_object('url', CAST(@url AS text)))) +
+			(SELECT COUNT(*) FROM comments WHERE image_url = @url) +
+			(SELECT COUNT(*) FROM stories WHERE media_url = @url) +
+			(SELECT COUNT(*) FROM users WHERE avatar_url = @url OR cover_url = @url)`,
+			map[string]interface{}{"url": url}).Scan(&refs).Error; err != nil {
+			return nil, err
+		}
+		if refs == 0 {
+			orphaned = append(orphaned, url)
+		}
+	}
+	return orphaned, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"slices"
+	"testing"
+)
+
+func TestUnreferencedUploadsOnlyPostAndStoryFiles(t *testing.T) {
+	// Every reference count comes back as zero, so only the path filter
+	// decides what may be removed.
+	db := feedRowDB(t, map[string]driver.Value{"refs": int64(0)})
+	urls := []string{
+		"/uploads/posts/a.jpg",
+		"/uploads/posts/a.jpg",
+		"/uploads/stories/s.mp4",
+		"/uploads/avatars/default/avatar1.svg",
+		"/uploads/avatars/u1/set_512.jpg",
+		"/uploads/covers/u1/set_1500.jpg",
+		"/uploads/posts/../avatars/u1/set_512.jpg",
+		"https://example.com/x.jpg",
+		"",
+	}
+	got, err := unreferencedUploads(db, urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/uploads/posts/a.jpg", "/uploads/stories/s.mp4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("unreferencedUploads = %v, want %v", got, want)
+	}
+}
+
+func TestUnreferencedUploadsKeepsReferencedFiles(t *testing.T) {
+	db := feedRowDB(t, map[string]driver.Value{"refs": int64(1)})
+	got, err := unreferencedUploads(db, []string{"/uploads/posts/a.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unreferencedUploads = %v, want none", got)
+	}
+}
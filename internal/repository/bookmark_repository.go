@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkRepository struct {
+	db *gorm.DB
+}
+
+// BookmarkedPost is a bookmark together with the post it points to, in the
+// shape of the feed.
+type BookmarkedPost struct {
+	FeedPost
+	BookmarkID   string
+	CollectionID *string
+	BookmarkedAt time.Time
+}
+
+// CollectionSummary is a collection with the number of live posts in it.
+type CollectionSummary struct {
+	models.Collection
+	BookmarksCount int `json:"bookmarks_count"`
+}
+
+// ownCollection checks that collectionID, if set, belongs to userID.
+func ownCollection(tx *gorm.DB, userID string, collectionID *string) error {
+	if collectionID == nil {
+		return nil
+	}
+	var exists bool
+	if err := tx.Model(&models.Collection{}).Select("count(*) > 0").
+		Where("id = ? AND user_id = ?", *collectionID, userID).Find(&exists).Error; err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("collection_not_found")
+	}
+	return nil
+}
+
+// Bookmark saves postID for userID, filed under collectionID when it is
+// set. Bookmarking an already bookmarked post keeps the bookmark and only
+// moves it if a collection is given.
+func (r BookmarkRepository) Bookmark(ctx context.Context, userID, postID string, collectionID *string) (*models.Bookmark, error) {
+	var bookmark models.Bookmark
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var exists bool
+		if err := tx.Model(&models.Post{}).Select("count(*) > 0").Where("id = ?", postID).Scopes(visiblePosts(userID)).Find(&exists).Error; err != nil {
+			return err
+		}
+		if !exists {
+			return gorm.ErrRecordNotFound
+		}
+		if err := ownCollection(tx, userID, collectionID); err != nil {
+			return err
+		}
+
+		onConflict := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+			DoNothing: true,
+		}
+		if collectionID != nil {
+			onConflict = clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+			}
+		}
+		if err := tx.Clauses(onConflict).Create(&models.Bookmark{UserID: userID, PostID: postID, CollectionID: collectionID}).Error; err != nil {
+			return err
+		}
+		return tx.First(&bookmark, "user_id = ? AND post_id = ?", userID, postID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// Unbookmark removes userID's bookmark of postID, if there is one.
+func (r BookmarkRepository) Unbookmark(ctx context.Context, userID, postID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&models.Bookmark{}).Error
+}
+
+// Move files the bookmark of postID under collectionID, or takes it out of
+// its collection when collectionID is nil.
+func (r BookmarkRepository) Move(ctx context.Context, userID, postID string, collectionID *string) (*models.Bookmark, error) {
+	var bookmark models.Bookmark
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownCollection(tx, userID, collectionID); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("post_id IN (?)", tx.Model(&models.Post{}).Select("id").Scopes(visiblePosts(userID))).
+			First(&bookmark, "user_id = ? AND post_id = ?", userID, postID).Error; err != nil {
+			return err
+		}
+		bookmark.CollectionID = collectionID
+		return tx.Model(&bookmark).UpdateColumn("collection_id", collectionID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// List returns userID's bookmarks, most recently saved first, optionally
+// limited to one collection. Bookmarks whose post is deleted, hidden from
+// the user, or whose author is gone, are skipped; they come back if the
+// post is restored or shared with them again.
+func (r BookmarkRepository) List(ctx context.Context, userID string, collectionID *string, after *FeedCursor, limit int) ([]BookmarkedPost, error) {
+	params := map[string]interface{}{
+		"viewer": userID,
+		"limit":  limit,
+	}
+	filterSQL := ""
+	if collectionID != nil {
+		filterSQL += " AND b.collection_id = @collection"
+		params["collection"] = *collectionID
+	}
+	if after != nil {
+		filterSQL += " AND (b.created_at, b.id) < (@ct, @cid)"
+		params["ct"] = after.CreatedAt
+		params["cid"] = after.ID
+	}
+
+	var posts []BookmarkedPost
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT `+feedPostColumns+`,
+			b.id AS bookmark_id,
+			b.collection_id,
+			b.created_at AS bookmarked_at
+		FROM bookmarks b
+		JOIN posts p ON p.id = b.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE b.user_id = @viewer
+			AND p.deleted_at IS NULL
+			AND u.is_active
+			AND `+visibleTo("p")+`
+			`+filterSQL+`
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT @limit`, params).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// BookmarkedPostIDs reports which of postIDs userID has bookmarked.
+func (r BookmarkRepository) BookmarkedPostIDs(ctx context.Context, userID string, postIDs []string) (map[string]bool, error) {
+	bookmarked := make(map[string]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&models.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
+
+func (r BookmarkRepository) ListCollections(ctx context.Context, userID string) ([]CollectionSummary, error) {
+	var collections []CollectionSummary
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT c.*,
+			(SELECT COUNT(*) FROM bookmarks b
+				JOIN posts p ON p.id = b.post_id AND p.deleted_at IS NULL
+				WHERE b.collection_id = c.id AND `+visibleTo("p")+`) AS bookmarks_count
+		FROM collections c
+		WHERE c.user_id = @viewer
+		ORDER BY c.name`, map[string]interface{}{"viewer": userID}).Scan(&collections).Error
+	if err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r BookmarkRepository) CreateCollection(ctx context.Context, userID, name string) (*models.Collection, error) {
+	collection := &models.Collection{UserID: userID, Name: name}
+	if err := r.db.WithContext(ctx).Create(collection).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("collection_exists")
+		}
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (r BookmarkRepository) RenameCollection(ctx context.Context, userID, collectionID, name string) (*models.Collection, error) {
+	var collection models.Collection
+	if err := r.db.WithContext(ctx).First(&collection, "id = ? AND user_id = ?", collectionID, userID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&collection).Update("name", name).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("collection_exists")
+		}
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// GetBookmarksForExport lists all of userID's bookmarks and collections,
+// including bookmarks of posts they can no longer see.
+func (r BookmarkRepository) GetBookmarksForExport(ctx context.Context, userID string) ([]models.Collection, []models.Bookmark, error) {
+	var collections []models.Collection
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name").Find(&collections).Error; err != nil {
+		return nil, nil, err
+	}
+	var bookmarks []models.Bookmark
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&bookmarks).Error; err != nil {
+		return nil, nil, err
+	}
+	return collections, bookmarks, nil
+}
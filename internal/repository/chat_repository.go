@@ -68,6 +68,7 @@ func (r ChatRepository) ListUserConversations(ctx context.Context, userID string
 func (r ChatRepository) ListMessages(ctx context.Context, conversationID string, limit, offset int) ([]models.Message, error) {
 	var msgs []models.Message
 	err := r.db.WithContext(ctx).
+		Preload("Mentions", orderedMentions).
 		Where("conversation_id = ?", conversationID).
 		Order("created_at DESC").
 		Limit(limit).Offset(offset).
@@ -75,6 +76,16 @@ func (r ChatRepository) ListMessages(ctx context.Context, conversationID string,
 	return msgs, err
 }
 
+func (r ChatRepository) ListParticipantIDs(ctx context.Context, conversationID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ?", conversationID).
+		Order("joined_at ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
 func (r ChatRepository) UpdateLastRead(ctx context.Context, conversationID, userID string, t time.Time) error {
 	return r.db.WithContext(ctx).
 		Model(&models.ConversationParticipant{}).
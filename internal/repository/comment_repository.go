@@ -11,12 +11,26 @@ type CommentRepository struct {
 	db *gorm.DB
 }
 
-func (r CommentRepository) GetCommentsByPost(ctx context.Context, postId string) ([]*models.Comment, error) {
+// onLivePost hides comments whose post sits in its author's trash.
+const onLivePost = "EXISTS (SELECT 1 FROM posts WHERE posts.id = comments.post_id AND posts.deleted_at IS NULL)"
+
+// onVisiblePost also hides comments on posts viewerID isn't allowed to see.
+func onVisiblePost(viewerID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("EXISTS (SELECT 1 FROM posts WHERE posts.id = comments.post_id AND posts.deleted_at IS NULL AND "+visibleTo("posts")+")",
+			map[string]interface{}{"viewer": viewerID})
+	}
+}
+
+// GetCommentsByPost lists the comments on a post viewerID can see; hidden
+// posts are reported as not found.
+func (r CommentRepository) GetCommentsByPost(ctx context.Context, postId, viewerID string) ([]*models.Comment, error) {
 	var exists bool
 	if err := r.db.WithContext(ctx).
 		Model(&models.Post{}).
 		Select("count(*) > 0").
 		Where("id = ?", postId).
+		Scopes(visiblePosts(viewerID)).
 		Find(&exists).Error; err != nil {
 		return nil, err
 	}
@@ -29,6 +43,7 @@ func (r CommentRepository) GetCommentsByPost(ctx context.Context, postId string)
 		Preload("User").
 		Preload("Post").
 		Preload("Post.User").
+		Preload("Mentions", orderedMentions).
 		Where("post_id = ?", postId).
 		Order("created_at ASC").
 		Find(&comments).Error; err != nil {
@@ -37,13 +52,14 @@ func (r CommentRepository) GetCommentsByPost(ctx context.Context, postId string)
 	return comments, nil
 }
 
-func (r CommentRepository) GetCommentById(ctx context.Context, id string) (*models.Comment, error) {
+func (r CommentRepository) GetCommentById(ctx context.Context, id, viewerID string) (*models.Comment, error) {
 	var comment models.Comment
 	if err := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Post").
 		Preload("Post.User").
-		Where("id = ?", id).First(&comment).Error; err != nil {
+		Preload("Mentions", orderedMentions).
+		Where("id = ?", id).Scopes(onVisiblePost(viewerID)).First(&comment).Error; err != nil {
 		return nil, err
 	}
 	return &comment, nil
@@ -55,7 +71,22 @@ func (r CommentRepository) GetCommentsByUser(ctx context.Context, userId string)
 		Preload("User").
 		Preload("Post").
 		Preload("Post.User").
-		Where("user_id = ?", userId).Find(&comments).Error; err != nil {
+		Preload("Mentions", orderedMentions).
+		Where("user_id = ?", userId).Scopes(onVisiblePost(userId)).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// GetCommentsForExport lists every comment userID wrote, for their data
+// export. Unlike GetCommentsByUser it keeps trashed comments and comments on
+// posts that are trashed or no longer visible to them.
+func (r CommentRepository) GetCommentsForExport(ctx context.Context, userID string) ([]models.Comment, error) {
+	var comments []models.Comment
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&comments).Error; err != nil {
 		return nil, err
 	}
 	return comments, nil
@@ -70,13 +101,20 @@ func (r CommentRepository) CreateComment(ctx context.Context, comment *models.Co
 		Model(&models.Post{}).
 		Select("count(*) > 0").
 		Where("id = ?", comment.PostID).
+		Scopes(visiblePosts(comment.UserID)).
 		Find(&exists).Error; err != nil {
 		return err
 	}
 	if !exists {
 		return gorm.ErrRecordNotFound
 	}
-	return r.db.WithContext(ctx).Create(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Post{}).Where("id = ?", comment.PostID).
+			UpdateColumn("comments_count", gorm.Expr("comments_count + 1")).Error
+	})
 }
 
 func (r CommentRepository) GetByIdWithRelations(ctx context.Context, id string) (*models.Comment, error) {
@@ -85,8 +123,33 @@ func (r CommentRepository) GetByIdWithRelations(ctx context.Context, id string)
 		Preload("User").
 		Preload("Post").
 		Preload("Post.User").
+		Preload("Mentions", orderedMentions).
+		Where(onLivePost).
 		First(&c, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &c, nil
 }
+
+// DeleteCommentByUser moves the comment to its author's trash and recounts
+// the post's comments.
+func (r CommentRepository) DeleteCommentByUser(ctx context.Context, commentID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Where(onLivePost).First(&comment, "id = ? AND user_id = ?", commentID, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		return recountComments(tx, comment.PostID)
+	})
+}
+
+// recountComments sets comments_count from the live comments, the same way
+// the account purge does, so a counter that drifted is corrected by the next
+// delete or restore instead of being carried forward.
+func recountComments(tx *gorm.DB, postID string) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("comments_count", gorm.Expr("(SELECT COUNT(*) FROM comments c WHERE c.post_id = ? AND c.deleted_at IS NULL)", postID)).Error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DataExportRepository struct {
+	db *gorm.DB
+}
+
+// CreateForUser queues a new export unless one was requested after since.
+// Failed exports don't count, so the user can retry right away.
+// The user row is locked so two concurrent requests can't both pass the
+// check. On "export_rate_limited" the previous export is returned.
+func (r DataExportRepository) CreateForUser(ctx context.Context, userID string, since time.Time) (*models.DataExport, error) {
+	var export *models.DataExport
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		var last models.DataExport
+		err := tx.Where("user_id = ? AND created_at > ? AND status <> ?", userID, since, models.DataExportFailed).
+			Order("created_at DESC").First(&last).Error
+		if err == nil {
+			export = &last
+			return errors.New("export_rate_limited")
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		export = &models.DataExport{UserID: userID, Status: models.DataExportPending}
+		return tx.Create(export).Error
+	})
+	return export, err
+}
+
+func (r DataExportRepository) GetByID(ctx context.Context, id string) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r DataExportRepository) GetByIDForUser(ctx context.Context, id, userID string) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.WithContext(ctx).First(&export, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r DataExportRepository) ListByUser(ctx context.Context, userID string, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// ClaimNext moves the oldest pending export to processing. SKIP LOCKED lets
+// several workers poll the same table without picking the same job. It
+// returns nil when the queue is empty.
+func (r DataExportRepository) ClaimNext(ctx context.Context) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.DataExportPending).
+			Order("created_at ASC").
+			First(&export).Error; err != nil {
+			return err
+		}
+		return tx.Model(&export).Update("status", models.DataExportProcessing).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// RequeueStale returns exports stuck in processing (e.g. after a restart
+// mid-build) to the queue.
+func (r DataExportRepository) RequeueStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.DataExport{}).
+		Where("status = ? AND updated_at < ?", models.DataExportProcessing, before).
+		Update("status", models.DataExportPending).Error
+}
+
+func (r DataExportRepository) MarkReady(ctx context.Context, id, filePath string, size int64, tokenHash string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.DataExportReady,
+		"file_path":    filePath,
+		"size_bytes":   size,
+		"token_hash":   tokenHash,
+		"expires_at":   expiresAt,
+		"completed_at": time.Now(),
+	}).Error
+}
+
+func (r DataExportRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	return r.db.WithContext(ctx).Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": models.DataExportFailed,
+		"error":  reason,
+	}).Error
+}
+
+func (r DataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.DataExportReady, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r DataExportRepository) MarkExpired(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.DataExportExpired,
+		"file_path":  "",
+		"token_hash": "",
+	}).Error
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rowsConnector is a database/sql connector whose every query returns the
+// same single row, so the raw feed queries can be scanned without Postgres.
+type rowsConnector struct {
+	columns []string
+	values  []driver.Value
+}
+
+func (c rowsConnector) Connect(context.Context) (driver.Conn, error) { return rowsConn(c), nil }
+func (c rowsConnector) Driver() driver.Driver                        { return nil }
+
+type rowsConn rowsConnector
+
+func (rowsConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (rowsConn) Close() error                        { return nil }
+func (rowsConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c rowsConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &singleRow{columns: c.columns, values: c.values}, nil
+}
+
+type singleRow struct {
+	columns []string
+	values  []driver.Value
+	done    bool
+}
+
+func (r *singleRow) Columns() []string { return r.columns }
+func (r *singleRow) Close() error      { return nil }
+
+func (r *singleRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func feedRowDB(t *testing.T, row map[string]driver.Value) *gorm.DB {
+	t.Helper()
+	c := rowsConnector{}
+	for col, v := range row {
+		c.columns = append(c.columns, col)
+		c.values = append(c.values, v)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(c)}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestFeedQueriesScanRow(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	row := map[string]driver.Value{
+		"id":                 "p1",
+		"user_id":            "u1",
+		"content":            "hello @bob #go",
+		"audience":           "public",
+		"created_at":         now,
+		"author_username":    "alice",
+		"author_is_verified": true,
+		"liked_by_me":        true,
+		"bookmarked_by_me":   true,
+		"media":              []byte(`[{"id":"m1","position":0,"url":"/uploads/a.png","type":"image","alt_text":"a cat"}]`),
+		"mentions":           `[{"user_id":"u2","username":"bob","offset":6,"length":4}]`,
+		"quoted_post":        []byte(`{"id":"q1","user_id":"u3","content":"quoted","author_username":"carol"}`),
+		"entry_id":           "p1",
+		"entry_at":           now,
+		"author_followed":    true,
+		"social_proof":       int64(2),
+		"bookmark_id":        "b1",
+		"bookmarked_at":      now,
+	}
+
+	check := func(t *testing.T, p FeedPost) {
+		t.Helper()
+		if p.ID != "p1" || p.AuthorUsername != "alice" || !p.LikedByMe || !p.BookmarkedByMe {
+			t.Errorf("post columns not scanned: %+v", p)
+		}
+		if len(p.Media) != 1 || p.Media[0].AltText != "a cat" {
+			t.Errorf("media = %+v", p.Media)
+		}
+		if len(p.Mentions) != 1 || p.Mentions[0].Username != "bob" || p.Mentions[0].Offset != 6 {
+			t.Errorf("mentions = %+v", p.Mentions)
+		}
+		if !p.QuotedPost.Valid || p.QuotedPost.ID != "q1" || p.QuotedPost.AuthorUsername != "carol" {
+			t.Errorf("quoted post = %+v", p.QuotedPost)
+		}
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		scan func(db *gorm.DB) ([]FeedPost, error)
+	}{
+		{"Feed", func(db *gorm.DB) ([]FeedPost, error) {
+			entries, err := PostRepository{db}.Feed(ctx, "v1", nil, 10)
+			var posts []FeedPost
+			for _, e := range entries {
+				if e.EntryID != "p1" || !e.EntryAt.Equal(now) {
+					t.Errorf("entry columns not scanned: %+v", e)
+				}
+				posts = append(posts, e.FeedPost)
+			}
+			return posts, err
+		}},
+		{"GetAllPosts", func(db *gorm.DB) ([]FeedPost, error) {
+			return PostRepository{db}.GetAllPosts(ctx, "v1", nil, 10)
+		}},
+		{"GetOwnPosts", func(db *gorm.DB) ([]FeedPost, error) {
+			return PostRepository{db}.GetOwnPosts(ctx, "u1", nil, 10)
+		}},
+		{"ByHashtag", func(db *gorm.DB) ([]FeedPost, error) {
+			return PostRepository{db}.ByHashtag(ctx, "v1", "go", nil, 10)
+		}},
+		{"RankingCandidates", func(db *gorm.DB) ([]FeedPost, error) {
+			candidates, err := PostRepository{db}.RankingCandidates(ctx, "v1", now.Add(-time.Hour), now, 10)
+			var posts []FeedPost
+			for _, c := range candidates {
+				if !c.AuthorFollowed || c.SocialProof != 2 {
+					t.Errorf("ranking columns not scanned: %+v", c)
+				}
+				posts = append(posts, c.FeedPost)
+			}
+			return posts, err
+		}},
+		{"Bookmarks", func(db *gorm.DB) ([]FeedPost, error) {
+			bookmarks, err := BookmarkRepository{db}.List(ctx, "v1", nil, nil, 10)
+			var posts []FeedPost
+			for _, b := range bookmarks {
+				if b.BookmarkID != "b1" {
+					t.Errorf("bookmark columns not scanned: %+v", b)
+				}
+				posts = append(posts, b.FeedPost)
+			}
+			return posts, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, err := tt.scan(feedRowDB(t, row))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(posts) != 1 {
+				t.Fatalf("got %d rows, want 1", len(posts))
+			}
+			check(t, posts[0])
+		})
+	}
+}
@@ -118,3 +118,32 @@ func (r FollowRepository) CountFollowing(ctx context.Context, userID string) (in
 	}
 	return cnt, nil
 }
+
+// SetCloseFriend adds friendID to or removes them from userID's close
+// friends. Only followers can be close friends; removing someone who isn't
+// one is a no-op.
+func (r FollowRepository) SetCloseFriend(ctx context.Context, userID, friendID string, closeFriend bool) error {
+	res := r.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("follower_id = ? AND following_id = ?", friendID, userID).
+		Update("close_friend", closeFriend)
+	if res.Error != nil {
+		return res.Error
+	}
+	if closeFriend && res.RowsAffected == 0 {
+		return errors.New("not_a_follower")
+	}
+	return nil
+}
+
+func (r FollowRepository) GetCloseFriends(ctx context.Context, userID string, limit, offset int) ([]models.User, error) {
+	var users []models.User
+	q := r.db.WithContext(ctx).Model(&models.User{}).
+		Joins("JOIN follows f ON f.follower_id = users.id").
+		Where("f.following_id = ? AND f.close_friend", userID).
+		Order("f.created_at DESC").
+		Limit(limit).Offset(offset)
+	if err := q.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HashtagRepository struct {
+	db *gorm.DB
+}
+
+// syncHashtags makes the post's tag links match the tags in content. Links
+// that survive an edit keep their original CreatedAt so re-saving a post
+// doesn't make its tags trend again.
+func syncHashtags(tx *gorm.DB, postID, content string) error {
+	names := utils.ExtractHashtags(content)
+	if len(names) == 0 {
+		return tx.Where("post_id = ?", postID).Delete(&models.PostHashtag{}).Error
+	}
+
+	tags := make([]models.Hashtag, len(names))
+	for i, name := range names {
+		tags[i] = models.Hashtag{Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&tags).Error; err != nil {
+		return err
+	}
+	var ids []string
+	if err := tx.Model(&models.Hashtag{}).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("post_id = ? AND hashtag_id NOT IN ?", postID, ids).Delete(&models.PostHashtag{}).Error; err != nil {
+		return err
+	}
+	links := make([]models.PostHashtag, len(ids))
+	for i, id := range ids {
+		links[i] = models.PostHashtag{PostID: postID, HashtagID: id}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+func (r HashtagRepository) GetByName(ctx context.Context, name string) (*models.Hashtag, error) {
+	var tag models.Hashtag
+	if err := r.db.WithContext(ctx).First(&tag, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Trending returns the snapshot written by the last RefreshTrending.
+func (r HashtagRepository) Trending(ctx context.Context, limit int) ([]models.TrendingHashtag, error) {
+	var tags []models.TrendingHashtag
+	err := r.db.WithContext(ctx).Order("rank ASC").Limit(limit).Find(&tags).Error
+	return tags, err
+}
+
+type TrendingParams struct {
+	// Window is the recent period whose activity is scored.
+	Window time.Duration
+	// Baseline is the longer period, ending at now, that sets how much
+	// activity a tag normally gets. It must be longer than Window.
+	Baseline   time.Duration
+	MinAuthors int
+	Limit      int
+}
+
+// RefreshTrending recomputes the trending snapshot. A tag's velocity is the
+// number of distinct authors using it in the last Window compared with what
+// its rate over the rest of Baseline predicts:
+//
+//	score = (recent - expected) / sqrt(expected + 1)
+//
+// so a small tag that suddenly picks up scores above a big one that is merely
+// steady. Counting authors rather than posts keeps one account from pushing a
+// tag by itself. Only live public posts of active accounts count, so
+// trends never hint at what's in restricted posts.
+func (r HashtagRepository) RefreshTrending(ctx context.Context, now time.Time, p TrendingParams) ([]models.TrendingHashtag, error) {
+	var tags []models.TrendingHashtag
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`
+			WITH uses AS (
+				SELECT ph.hashtag_id, ph.created_at, p.user_id
+				FROM post_hashtags ph
+				JOIN posts p ON p.id = ph.post_id AND p.deleted_at IS NULL AND p.audience = 'public'
+				JOIN users u ON u.id = p.user_id AND u.is_active
+				WHERE ph.created_at > @baseline_start
+			), stats AS (
+				SELECT hashtag_id,
+					COUNT(*) FILTER (WHERE created_at > @window_start) AS uses,
+					COUNT(DISTINCT user_id) FILTER (WHERE created_at > @window_start) AS authors,
+					COUNT(DISTINCT user_id) FILTER (WHERE created_at <= @window_start) * @ratio AS expected
+				FROM uses
+				GROUP BY hashtag_id
+			)
+			SELECT ROW_NUMBER() OVER (ORDER BY s.score DESC, s.authors DESC, s.name) AS rank, s.*
+			FROM (
+				SELECT h.name, st.uses, st.authors,
+					((st.authors - st.expected) / SQRT(st.expected + 1))::float8 AS score
+				FROM stats st
+				JOIN hashtags h ON h.id = st.hashtag_id
+				WHERE st.authors >= @min_authors
+			) s
+			WHERE s.score > 0
+			ORDER BY rank
+			LIMIT @limit`, map[string]interface{}{
+			"baseline_start": now.Add(-p.Baseline),
+			"window_start":   now.Add(-p.Window),
+			"ratio":          p.Window.Hours() / (p.Baseline - p.Window).Hours(),
+			"min_authors":    p.MinAuthors,
+			"limit":          p.Limit,
+		}).Scan(&tags).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("1 = 1").Delete(&models.TrendingHashtag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		for i := range tags {
+			tags[i].ComputedAt = now
+		}
+		return tx.Create(&tags).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -57,12 +57,23 @@ func (r LikeRepository) CountStoryLikes(ctx context.Context, storyID string) (in
 	return cnt, nil
 }
 
+func (r LikeRepository) GetLikesByUser(ctx context.Context, userID string) ([]models.Like, error) {
+	var likes []models.Like
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&likes).Error; err != nil {
+		return nil, err
+	}
+	return likes, nil
+}
+
 func (r LikeRepository) toggle(ctx context.Context, userID string, postID, storyID *string) (bool, error) {
 	var liked bool
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var exists bool
 		if postID != nil {
-			if err := tx.Model(&models.Post{}).Select("count(*) > 0").Where("id = ?", *postID).Find(&exists).Error; err != nil {
+			if err := tx.Model(&models.Post{}).Select("count(*) > 0").Where("id = ?", *postID).Scopes(visiblePosts(userID)).Find(&exists).Error; err != nil {
 				return err
 			}
 			if !exists {
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type MentionRepository struct {
+	db *gorm.DB
+}
+
+// orderedMentions preloads mentions in text order.
+func orderedMentions(db *gorm.DB) *gorm.DB {
+	return db.Order("\"offset\" ASC")
+}
+
+// Replace swaps the stored mentions of a source for mentions and returns the
+// IDs of users who weren't mentioned there before, so an edit only notifies
+// the newcomers.
+func (r MentionRepository) Replace(ctx context.Context, sourceType, sourceID string, mentions []models.Mention) ([]string, error) {
+	var added []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before []string
+		if err := tx.Model(&models.Mention{}).
+			Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+			Distinct().Pluck("user_id", &before).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+			Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(before))
+		for _, id := range before {
+			seen[id] = true
+		}
+		for i := range mentions {
+			mentions[i].SourceType = sourceType
+			mentions[i].SourceID = sourceID
+			if !seen[mentions[i].UserID] {
+				seen[mentions[i].UserID] = true
+				added = append(added, mentions[i].UserID)
+			}
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		return tx.Create(&mentions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
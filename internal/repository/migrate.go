@@ -15,6 +15,8 @@ func MigrateDatabase(db *gorm.DB) error {
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
+		&models.PostMedia{},
+		&models.PostRevision{},
 		&models.Story{},
 		&models.Like{},
 		&models.Comment{},
@@ -25,6 +27,19 @@ func MigrateDatabase(db *gorm.DB) error {
 		&models.Message{},
 		&models.Skill{},
 		&models.Notification{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
+		&models.RoleChange{},
+		&models.DataExport{},
+		&models.Hashtag{},
+		&models.PostHashtag{},
+		&models.TrendingHashtag{},
+		&models.Mention{},
+		&models.Repost{},
+		&models.Collection{},
+		&models.Bookmark{},
 	)
 	if err != nil {
 		return err
@@ -98,6 +113,20 @@ func createIndexes(db *gorm.DB) error {
 		return err
 	}
 
+	// User search
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (username gin_trgm_ops)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_first_name_trgm ON users USING gin (first_name gin_trgm_ops)").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_last_name_trgm ON users USING gin (last_name gin_trgm_ops)").Error; err != nil {
+		return err
+	}
+
 	return nil
 }
 
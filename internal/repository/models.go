@@ -14,6 +14,16 @@ type Models struct {
 	Chat              ChatRepository
 	Skills            SkillRepository
 	Notifications     NotificationRepository
+	RefreshTokens     RefreshTokenRepository
+	Sessions          SessionRepository
+	RecoveryCodes     RecoveryCodeRepository
+	LoginAttempts     LoginAttemptRepository
+	DataExports       DataExportRepository
+	Trash             TrashRepository
+	Hashtags          HashtagRepository
+	Mentions          MentionRepository
+	Reposts           RepostRepository
+	Bookmarks         BookmarkRepository
 }
 
 func NewModels(db *gorm.DB) *Models {
@@ -29,5 +39,15 @@ func NewModels(db *gorm.DB) *Models {
 		Chat:              NewChatRepository(db),
 		Skills:            SkillRepository{db: db},
 		Notifications:     NotificationRepository{db: db},
+		RefreshTokens:     RefreshTokenRepository{db: db},
+		Sessions:          SessionRepository{db: db},
+		RecoveryCodes:     RecoveryCodeRepository{db: db},
+		LoginAttempts:     LoginAttemptRepository{db: db},
+		DataExports:       DataExportRepository{db: db},
+		Trash:             TrashRepository{db: db},
+		Hashtags:          HashtagRepository{db: db},
+		Mentions:          MentionRepository{db: db},
+		Reposts:           RepostRepository{db: db},
+		Bookmarks:         BookmarkRepository{db: db},
 	}
 }
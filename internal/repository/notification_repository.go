@@ -74,6 +74,14 @@ func (r NotificationRepository) DeleteByActorAndType(ctx context.Context, userID
 		Delete(&models.Notification{}).Error
 }
 
+// DeleteByTarget withdraws actorID's notification of notifType about
+// targetID, e.g. when a repost is undone.
+func (r NotificationRepository) DeleteByTarget(ctx context.Context, userID, actorID string, notifType models.NotificationType, targetID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND actor_id = ? AND type = ? AND target_id = ?", userID, actorID, notifType, targetID).
+		Delete(&models.Notification{}).Error
+}
+
 func (r NotificationRepository) Exists(ctx context.Context, userID, actorID string, notifType models.NotificationType, targetID *string) (bool, error) {
 	var count int64
 	query := r.db.WithContext(ctx).
@@ -2,67 +2,229 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"modern-social-media/internal/models"
+	"time"
 	
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository struct {
 	db *gorm.DB
 }
 
-func (r PostRepository) GetAllPosts(ctx context.Context) ([]models.Post, error) {
-	var posts []models.Post
+// GetAllPosts lists live posts from every active account that viewerID is
+// allowed to see, newest first, in the same shape and with the same cursor
+// as Feed.
+func (r PostRepository) GetAllPosts(ctx context.Context, viewerID string, after *FeedCursor, limit int) ([]FeedPost, error) {
+	params := map[string]interface{}{
+		"viewer": viewerID,
+		"limit":  limit,
+	}
+	cursorSQL := ""
+	if after != nil {
+		cursorSQL = "AND (p.created_at, p.id) < (@ct, @cid)"
+		params["ct"] = after.CreatedAt
+		params["cid"] = after.ID
+	}
 
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		Find(&posts).Error; err != nil {
+	var posts []FeedPost
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT `+feedPostColumns+`
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.deleted_at IS NULL
+			AND u.is_active
+			AND `+visibleTo("p")+`
+			`+cursorSQL+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT @limit`, params).Scan(&posts).Error
+	if err != nil {
 		return nil, err
 	}
-
 	return posts, nil
 }
 
-func (r PostRepository) GetById(ctx context.Context, id string) (*models.Post, error) {
+// GetById loads a post as viewerID sees it. Posts hidden from the viewer
+// are reported as not found, so their existence isn't revealed.
+func (r PostRepository) GetById(ctx context.Context, id, viewerID string) (*models.Post, error) {
 	var post models.Post
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Media", orderedMedia).
+		Preload("Mentions", orderedMentions).
+		Preload("QuotedPost", visiblePosts(viewerID)).
+		Preload("QuotedPost.User").
+		Scopes(visiblePosts(viewerID)).
 		First(&post, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-func (r PostRepository) GetPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+// GetOwnPosts lists userID's live posts for userID themselves, newest first,
+// in the same shape and with the same cursor as Feed. Audience isn't checked,
+// so never call it on behalf of anyone else.
+func (r PostRepository) GetOwnPosts(ctx context.Context, userID string, after *FeedCursor, limit int) ([]FeedPost, error) {
+	params := map[string]interface{}{
+		"viewer": userID,
+		"limit":  limit,
+	}
+	cursorSQL := ""
+	if after != nil {
+		cursorSQL = "AND (p.created_at, p.id) < (@ct, @cid)"
+		params["ct"] = after.CreatedAt
+		params["cid"] = after.ID
+	}
+
+	var posts []FeedPost
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT `+feedPostColumns+`
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = @viewer
+			AND p.deleted_at IS NULL
+			`+cursorSQL+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT @limit`, params).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetPostsForExport lists every post userID wrote, trashed ones included,
+// for their data export.
+func (r PostRepository) GetPostsForExport(ctx context.Context, userID string) ([]models.Post, error) {
 	var posts []models.Post
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		Where("user_id = ?", userID).Find(&posts).Error; err != nil {
+	if err := r.db.WithContext(ctx).Unscoped().
+		Preload("Media", orderedMedia).
+		Preload("Mentions", orderedMentions).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&posts).Error; err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
 
+// CountByUser counts userID's posts that viewerID may see, so a profile
+// doesn't reveal how many posts are hidden from its visitor.
+func (r PostRepository) CountByUser(ctx context.Context, userID, viewerID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("user_id = ?", userID).
+		Scopes(visiblePosts(viewerID)).
+		Count(&count).Error
+	return count, err
+}
+
 func (r PostRepository) CreatePost(ctx context.Context, p *models.Post) error {
-	return r.db.WithContext(ctx).Create(p).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		return syncHashtags(tx, p.ID, p.Content)
+	})
 }
 
-func (r PostRepository) UpdatePostByUser(ctx context.Context, postID, userID string, p *models.Post) error {
-	var post models.Post
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		First(&post, "id = ? AND user_id = ?", postID, userID).Error; err != nil {
-		return err
-	}
-	post.Content = p.Content
-	post.ImageURL = p.ImageURL
+// UpdatePostByUser changes only the text. ImageURL follows the attachments,
+// so it is left to UpdatePostWithMedia.
+func (r PostRepository) UpdatePostByUser(ctx context.Context, postID, userID, content string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Media", orderedMedia).
+			First(&post, "id = ? AND user_id = ?", postID, userID).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, &post, content, post.ImageURL, post.Media); err != nil {
+			return err
+		}
+		if err := syncHashtags(tx, post.ID, content); err != nil {
+			return err
+		}
+		return tx.Model(&post).Update("content", content).Error
+	})
+}
+
+// UpdatePostWithMedia replaces the content and the attachment list in one
+// transaction. keepIDs lists the existing attachments to keep, in their new
+// order; added ones are appended after them. ImageURL follows the first image
+// so older clients still see a picture. Files of removed attachments stay on
+// disk because the revision saved here still shows them; the trash and
+// account purges delete them along with the revisions.
+func (r PostRepository) UpdatePostWithMedia(ctx context.Context, postID, userID, content string, keepIDs []string, added []models.PostMedia) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Media", orderedMedia).
+			First(&post, "id = ? AND user_id = ?", postID, userID).Error; err != nil {
+			return err
+		}
 
-	return r.db.WithContext(ctx).Save(&post).Error
+		existing := make(map[string]models.PostMedia, len(post.Media))
+		for _, m := range post.Media {
+			existing[m.ID] = m
+		}
+		media := make([]models.PostMedia, 0, len(keepIDs)+len(added))
+		for _, id := range keepIDs {
+			m, ok := existing[id]
+			if !ok {
+				return errors.New("unknown_media")
+			}
+			delete(existing, id)
+			media = append(media, m)
+		}
+		media = append(media, added...)
+
+		if len(existing) > 0 {
+			ids := make([]string, 0, len(existing))
+			for id := range existing {
+				ids = append(ids, id)
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&models.PostMedia{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range media {
+			media[i].Position = i
+			media[i].PostID = post.ID
+			if media[i].ID == "" {
+				if err := tx.Create(&media[i]).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(&media[i]).Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+
+		imageURL := ""
+		for _, m := range media {
+			if m.Type == models.MediaTypeImage {
+				imageURL = m.URL
+				break
+			}
+		}
+		if err := saveRevision(tx, &post, content, imageURL, media); err != nil {
+			return err
+		}
+		if err := syncHashtags(tx, post.ID, content); err != nil {
+			return err
+		}
+		return tx.Model(&post).Updates(map[string]interface{}{"content": content, "image_url": imageURL}).Error
+	})
 }
 
+// DeletePostByUser moves the post to the author's trash. Attachments, likes
+// and comments stay in place so a restore brings everything back.
 func (r PostRepository) DeletePostByUser(ctx context.Context, postID, userID string) error {
 	res := r.db.WithContext(ctx).
-		Preload("User").
 		Where("id = ? AND user_id = ?", postID, userID).Delete(&models.Post{})
 	if res.Error != nil {
 		return res.Error
@@ -73,3 +235,378 @@ func (r PostRepository) DeletePostByUser(ctx context.Context, postID, userID str
 	return nil
 }
 
+// SetAudience changes who can see the post. Only its author may do so.
+func (r PostRepository) SetAudience(ctx context.Context, postID, userID string, audience models.PostAudience) (*models.Post, error) {
+	res := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("id = ? AND user_id = ?", postID, userID).
+		Update("audience", audience)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetById(ctx, postID, userID)
+}
+
+// postVisibleTo holds when the user %[2]s may see the post aliased %[1]s: it
+// is theirs or public, followers-only and they follow the author, or
+// close-friends and the author marked them as a close friend.
+const postVisibleTo = `(%[1]s.user_id = %[2]s OR %[1]s.audience = 'public' OR (%[1]s.audience IN ('followers', 'close_friends') AND EXISTS (
+			SELECT 1 FROM follows vf
+			WHERE vf.follower_id = %[2]s AND vf.following_id = %[1]s.user_id
+				AND (%[1]s.audience = 'followers' OR vf.close_friend)
+		)))`
+
+// visibleTo is postVisibleTo for the viewer bound to @viewer.
+func visibleTo(alias string) string {
+	return fmt.Sprintf(postVisibleTo, alias, "@viewer")
+}
+
+// ViewersByUsername returns the IDs of the users among usernames who may see
+// postID.
+func (r PostRepository) ViewersByUsername(ctx context.Context, postID string, usernames []string) (map[string]bool, error) {
+	viewers := make(map[string]bool, len(usernames))
+	if len(usernames) == 0 {
+		return viewers, nil
+	}
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT u.id
+		FROM users u
+		JOIN posts p ON p.id = @post
+		WHERE u.username IN @usernames
+			AND `+fmt.Sprintf(postVisibleTo, "p", "u.id"), map[string]interface{}{
+		"post":      postID,
+		"usernames": usernames,
+	}).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		viewers[id] = true
+	}
+	return viewers, nil
+}
+
+// visiblePosts limits a posts query to what viewerID may see. An empty
+// viewerID stands for an anonymous caller, who only sees public posts.
+func visiblePosts(viewerID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(visibleTo("posts"), map[string]interface{}{"viewer": viewerID})
+	}
+}
+
+func orderedMedia(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// PostMediaList scans the json_agg of a post's attachments built by the feed
+// queries. Fields of this type need a gorm type tag, otherwise GORM takes a
+// slice of models for a has-many relation and refuses to scan into it.
+type PostMediaList []models.PostMedia
+
+func (l *PostMediaList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = PostMediaList{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return fmt.Errorf("unsupported media list type %T", src)
+}
+
+// postMediaJSON renders a post's attachments as a JSON array using the same
+// keys as models.PostMedia.
+const postMediaJSON = `COALESCE((
+			SELECT json_agg(json_build_object(
+				'id', m.id, 'position', m.position, 'url', m.url, 'type', m.type,
+				'mime_type', m.mime_type, 'width', m.width, 'height', m.height,
+				'size_bytes', m.size_bytes, 'alt_text', m.alt_text, 'created_at', m.created_at
+			) ORDER BY m.position)
+			FROM post_media m WHERE m.post_id = p.id
+		), '[]')`
+
+// MentionList scans the json_agg of a post's mentions built by the feed
+// queries. Like PostMediaList it needs a gorm type tag where it's used.
+type MentionList []models.Mention
+
+func (l *MentionList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = MentionList{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return fmt.Errorf("unsupported mention list type %T", src)
+}
+
+// postMentionsJSON renders a post's mentions as a JSON array using the same
+// keys as models.Mention.
+const postMentionsJSON = `COALESCE((
+			SELECT json_agg(json_build_object(
+				'user_id', mn.user_id, 'username', mn.username, 'offset', mn."offset", 'length', mn.length
+			) ORDER BY mn."offset")
+			FROM mentions mn WHERE mn.source_type = 'post' AND mn.source_id = p.id
+		), '[]')`
+
+// QuotedPostPreview is the embedded preview of the post a quote refers to.
+// Valid is false when the quoted post is deleted or its author is gone. As a
+// struct it needs a gorm type tag where it's used, or GORM won't map it to a
+// column at all.
+type QuotedPostPreview struct {
+	Valid            bool      `json:"-"`
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	Content          string    `json:"content"`
+	ImageURL         string    `json:"image_url"`
+	CreatedAt        time.Time `json:"created_at"`
+	AuthorUsername   string    `json:"author_username"`
+	AuthorFirstName  string    `json:"author_first_name"`
+	AuthorLastName   string    `json:"author_last_name"`
+	AuthorAvatarURL  string    `json:"author_avatar_url"`
+	AuthorIsVerified bool      `json:"author_is_verified"`
+}
+
+func (q *QuotedPostPreview) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*q = QuotedPostPreview{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported quoted post type %T", src)
+	}
+	if err := json.Unmarshal(raw, q); err != nil {
+		return err
+	}
+	q.Valid = true
+	return nil
+}
+
+// quotedPostJSON renders the live post p quotes, or NULL when it is gone or
+// hidden from @viewer, using the keys of QuotedPostPreview.
+var quotedPostJSON = `(
+			SELECT json_build_object(
+				'id', q.id, 'user_id', q.user_id, 'content', q.content, 'image_url', q.image_url,
+				'created_at', q.created_at, 'author_username', qu.username,
+				'author_first_name', qu.first_name, 'author_last_name', qu.last_name,
+				'author_avatar_url', qu.avatar_url, 'author_is_verified', qu.is_verified
+			)
+			FROM posts q JOIN users qu ON qu.id = q.user_id
+			WHERE q.id = p.quoted_post_id AND q.deleted_at IS NULL AND qu.is_active
+				AND ` + visibleTo("q") + `
+		)`
+
+// feedPostColumns selects everything FeedPost needs for post p written by u,
+// as seen by @viewer.
+var feedPostColumns = `p.*,
+			u.username AS author_username,
+			u.first_name AS author_first_name,
+			u.last_name AS author_last_name,
+			u.avatar_url AS author_avatar_url,
+			u.is_verified AS author_is_verified,
+			EXISTS (SELECT 1 FROM likes l WHERE l.post_id = p.id AND l.user_id = @viewer) AS liked_by_me,
+			EXISTS (SELECT 1 FROM reposts rp WHERE rp.post_id = p.id AND rp.user_id = @viewer) AS reposted_by_me,
+			EXISTS (SELECT 1 FROM bookmarks bm WHERE bm.post_id = p.id AND bm.user_id = @viewer) AS bookmarked_by_me,
+			` + postMediaJSON + ` AS media,
+			` + postMentionsJSON + ` AS mentions,
+			` + quotedPostJSON + ` AS quoted_post`
+
+type FeedPost struct {
+	models.Post
+	AuthorUsername   string
+	AuthorFirstName  string
+	AuthorLastName   string
+	AuthorAvatarURL  string
+	AuthorIsVerified bool
+	LikedByMe        bool
+	RepostedByMe     bool
+	BookmarkedByMe   bool
+	Media            PostMediaList     `gorm:"type:json"`
+	Mentions         MentionList       `gorm:"type:json"`
+	QuotedPost       QuotedPostPreview `gorm:"type:json"`
+}
+
+// FeedEntry is a home feed row: a post, or a repost of one by an account the
+// viewer follows. EntryID and EntryAt are the repost's when it is one and
+// the post's otherwise; the feed is ordered and paged by them.
+type FeedEntry struct {
+	FeedPost
+	EntryID            string
+	EntryAt            time.Time
+	RepostID           *string
+	ReposterID         *string
+	ReposterUsername   *string
+	ReposterFirstName  *string
+	ReposterLastName   *string
+	ReposterAvatarURL  *string
+	ReposterIsVerified *bool
+}
+
+// FeedCursor is the (created_at, id) of the last entry of the previous page.
+type FeedCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Feed returns the viewer's own posts and the posts and reposts of accounts
+// they follow, newest first. A reposted post shows up again at the time of
+// the repost, attributed to the reposter. Each branch is filtered and cut at
+// the cursor and limit before merging, so the cost stays bounded per page.
+func (r PostRepository) Feed(ctx context.Context, viewerID string, after *FeedCursor, limit int) ([]FeedEntry, error) {
+	params := map[string]interface{}{
+		"viewer": viewerID,
+		"limit":  limit,
+	}
+	postCursorSQL, repostCursorSQL := "", ""
+	if after != nil {
+		postCursorSQL = "AND (p.created_at, p.id) < (@ct, @cid)"
+		repostCursorSQL = "AND (r.created_at, r.id) < (@ct, @cid)"
+		params["ct"] = after.CreatedAt
+		params["cid"] = after.ID
+	}
+
+	var entries []FeedEntry
+	err := r.db.WithContext(ctx).Raw(`
+		WITH sources AS (
+			SELECT @viewer AS user_id
+			UNION SELECT f.following_id FROM follows f WHERE f.follower_id = @viewer
+		), entries AS (
+			(SELECT p.id AS post_id, p.id AS entry_id, p.created_at AS entry_at,
+				NULL::varchar(25) AS repost_id, NULL::varchar(25) AS reposter_id
+			FROM posts p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.user_id IN (SELECT user_id FROM sources)
+				AND p.deleted_at IS NULL
+				AND u.is_active
+				AND `+visibleTo("p")+`
+				`+postCursorSQL+`
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT @limit)
+			UNION ALL
+			(SELECT r.post_id, r.id, r.created_at, r.id, r.user_id
+			FROM reposts r
+			JOIN users ru ON ru.id = r.user_id
+			JOIN posts p ON p.id = r.post_id
+			JOIN users u ON u.id = p.user_id
+			WHERE r.user_id IN (SELECT user_id FROM sources)
+				AND ru.is_active
+				AND p.deleted_at IS NULL
+				AND u.is_active
+				AND `+visibleTo("p")+`
+				`+repostCursorSQL+`
+			ORDER BY r.created_at DESC, r.id DESC
+			LIMIT @limit)
+		)
+		SELECT `+feedPostColumns+`,
+			e.entry_id, e.entry_at, e.repost_id, e.reposter_id,
+			ru.username AS reposter_username,
+			ru.first_name AS reposter_first_name,
+			ru.last_name AS reposter_last_name,
+			ru.avatar_url AS reposter_avatar_url,
+			ru.is_verified AS reposter_is_verified
+		FROM entries e
+		JOIN posts p ON p.id = e.post_id
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN users ru ON ru.id = e.reposter_id
+		ORDER BY e.entry_at DESC, e.entry_id DESC
+		LIMIT @limit`, params).Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ByHashtag returns live posts tagged with tag, newest first, in the same
+// shape and with the same cursor as Feed.
+func (r PostRepository) ByHashtag(ctx context.Context, viewerID, tag string, after *FeedCursor, limit int) ([]FeedPost, error) {
+	params := map[string]interface{}{
+		"viewer": viewerID,
+		"tag":    tag,
+		"limit":  limit,
+	}
+	cursorSQL := ""
+	if after != nil {
+		cursorSQL = "AND (p.created_at, p.id) < (@ct, @cid)"
+		params["ct"] = after.CreatedAt
+		params["cid"] = after.ID
+	}
+
+	var posts []FeedPost
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT `+feedPostColumns+`
+		FROM hashtags h
+		JOIN post_hashtags ph ON ph.hashtag_id = h.id
+		JOIN posts p ON p.id = ph.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE h.name = @tag
+			AND p.deleted_at IS NULL
+			AND u.is_active
+			AND `+visibleTo("p")+`
+			`+cursorSQL+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT @limit`, params).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+type RankingCandidate struct {
+	FeedPost
+	AuthorFollowed bool
+	SocialProof    int
+	AuthorLikes    int
+}
+
+// RankingCandidates collects recent posts from other active accounts along
+// with the per-viewer signals the ranker needs. The scan is bounded by
+// (since, until] and limit, and the viewer's like history is capped to their latest 1000
+// likes, so the cost doesn't grow with the size of the table.
+func (r PostRepository) RankingCandidates(ctx context.Context, viewerID string, since, until time.Time, limit int) ([]RankingCandidate, error) {
+	var candidates []RankingCandidate
+	err := r.db.WithContext(ctx).Raw(`
+		WITH following AS (
+			SELECT following_id FROM follows WHERE follower_id = @viewer
+		), liked_authors AS (
+			SELECT p2.user_id, COUNT(*) AS n
+			FROM (SELECT post_id FROM likes WHERE user_id = @viewer AND post_id IS NOT NULL ORDER BY created_at DESC LIMIT 1000) l
+			JOIN posts p2 ON p2.id = l.post_id
+			GROUP BY p2.user_id
+		)
+		SELECT `+feedPostColumns+`,
+			p.user_id IN (SELECT following_id FROM following) AS author_followed,
+			(SELECT COUNT(*) FROM follows f WHERE f.following_id = p.user_id AND f.follower_id IN (SELECT following_id FROM following)) AS social_proof,
+			COALESCE(la.n, 0) AS author_likes
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN liked_authors la ON la.user_id = p.user_id
+		WHERE p.created_at > @since
+			AND p.created_at <= @until
+			AND p.deleted_at IS NULL
+			AND p.user_id <> @viewer
+			AND u.is_active
+			AND `+visibleTo("p")+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"viewer": viewerID,
+		"since":  since,
+		"until":  until,
+		"limit":  limit,
+	}).Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
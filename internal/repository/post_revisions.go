@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saveRevision archives the current version of a locked post before it is
+// overwritten with content, imageURL and media. post.Media must hold the
+// current attachments in order. Edits that change nothing are not recorded.
+func saveRevision(tx *gorm.DB, post *models.Post, content, imageURL string, media []models.PostMedia) error {
+	current := revisionMedia(post.Media)
+	if post.Content == content && post.ImageURL == imageURL && slices.Equal(current, revisionMedia(media)) {
+		return nil
+	}
+	publishedAt := post.CreatedAt
+	if post.EditedAt != nil {
+		publishedAt = *post.EditedAt
+	}
+	rev := &models.PostRevision{
+		PostID:      post.ID,
+		Number:      post.RevisionCount + 1,
+		Content:     post.Content,
+		ImageURL:    post.ImageURL,
+		Media:       current,
+		PublishedAt: publishedAt,
+	}
+	if err := tx.Create(rev).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	post.EditedAt = &now
+	post.RevisionCount++
+	return tx.Model(post).Updates(map[string]interface{}{"edited_at": now, "revision_count": post.RevisionCount}).Error
+}
+
+func revisionMedia(media []models.PostMedia) models.RevisionMedia {
+	snapshot := make(models.RevisionMedia, len(media))
+	for i, m := range media {
+		snapshot[i] = models.RevisionAttachment{URL: m.URL, Type: m.Type, AltText: m.AltText}
+	}
+	return snapshot
+}
+
+func (r PostRepository) ListRevisions(ctx context.Context, postID string) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := r.db.WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// GetRevisionsForExport lists the revisions of every post userID wrote,
+// trashed posts included.
+func (r PostRepository) GetRevisionsForExport(ctx context.Context, userID string) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := r.db.WithContext(ctx).
+		Joins("JOIN posts p ON p.id = post_revisions.post_id").
+		Where("p.user_id = ?", userID).
+		Order("post_revisions.post_id, post_revisions.number").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// RestoreRevision makes an older version current again. The version being
+// replaced is archived like any other edit, so a restore can be undone.
+// Attachments are not restored: the revision's media snapshot is for display
+// only. Posts with attachments keep their current image; only the text is
+// restored.
+func (r PostRepository) RestoreRevision(ctx context.Context, postID, userID string, number int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Media", orderedMedia).
+			First(&post, "id = ? AND user_id = ?", postID, userID).Error; err != nil {
+			return err
+		}
+		var rev models.PostRevision
+		if err := tx.First(&rev, "post_id = ? AND number = ?", postID, number).Error; err != nil {
+			return err
+		}
+
+		imageURL := rev.ImageURL
+		if len(post.Media) > 0 {
+			imageURL = post.ImageURL
+		}
+
+		if err := saveRevision(tx, &post, rev.Content, imageURL, post.Media); err != nil {
+			return err
+		}
+		if err := syncHashtags(tx, post.ID, rev.Content); err != nil {
+			return err
+		}
+		return tx.Model(&post).Updates(map[string]interface{}{"content": rev.Content, "image_url": imageURL}).Error
+	})
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r RefreshTokenRepository) Create(ctx context.Context, t *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	if err := r.db.WithContext(ctx).First(&t, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r RefreshTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", &now)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", &now).Error
+}
+
+func (r RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID, exceptFamilyID string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, exceptFamilyID).
+		Update("revoked_at", &now).Error
+}
+
+func (r RefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at <= NOW()").Delete(&models.RefreshToken{}).Error
+}
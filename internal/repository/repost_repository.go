@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepostRepository struct {
+	db *gorm.DB
+}
+
+// Repost shares postID on behalf of userID. Reposting twice is a no-op:
+// created reports whether a new repost was stored, and the counter only
+// moves when it was. The post row is locked so concurrent reposts and
+// un-reposts can't skew reposts_count.
+func (r RepostRepository) Repost(ctx context.Context, userID, postID string) (post *models.Post, created bool, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(visiblePosts(userID)).First(&p, "id = ?", postID).Error; err != nil {
+			return err
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Repost{UserID: userID, PostID: postID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			created = true
+			p.RepostsCount++
+			if err := tx.Model(&p).UpdateColumn("reposts_count", gorm.Expr("reposts_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+		post = &p
+		return nil
+	})
+	return post, created, err
+}
+
+// Unrepost removes userID's repost of postID. A repost can always be taken
+// back, even once the post is in the trash or hidden from userID. Without one
+// it succeeds only for posts userID can see, so it can't be used to probe
+// hidden posts.
+func (r RepostRepository) Unrepost(ctx context.Context, userID, postID string) (post *models.Post, removed bool, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p models.Post
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, "id = ?", postID).Error; err != nil {
+			return err
+		}
+
+		res := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Repost{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			var visible int64
+			if err := tx.Model(&models.Post{}).Scopes(visiblePosts(userID)).
+				Where("id = ?", postID).Count(&visible).Error; err != nil {
+				return err
+			}
+			if visible == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		} else {
+			removed = true
+			if p.RepostsCount > 0 {
+				p.RepostsCount--
+			}
+			if err := tx.Unscoped().Model(&p).UpdateColumn("reposts_count", gorm.Expr("GREATEST(reposts_count - 1, 0)")).Error; err != nil {
+				return err
+			}
+		}
+		post = &p
+		return nil
+	})
+	return post, removed, err
+}
+
+// GetRepostsByUser lists userID's reposts, newest first.
+func (r RepostRepository) GetRepostsByUser(ctx context.Context, userID string) ([]models.Repost, error) {
+	var reposts []models.Repost
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&reposts).Error; err != nil {
+		return nil, err
+	}
+	return reposts, nil
+}
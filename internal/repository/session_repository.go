@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func (r SessionRepository) Create(ctx context.Context, s *models.Session) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	var s models.Session
+	if err := r.db.WithContext(ctx).First(&s, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r SessionRepository) IsActive(ctx context.Context, id, userID string) (bool, error) {
+	var cnt int64
+	if err := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", id, userID).
+		Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+func (r SessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r SessionRepository) Touch(ctx context.Context, id, userAgent, ip string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"user_agent":   userAgent,
+			"ip":           ip,
+			"expires_at":   expiresAt,
+			"last_used_at": time.Now(),
+		}).Error
+}
+
+func (r SessionRepository) Revoke(ctx context.Context, id, userID string) (bool, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", &now)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r SessionRepository) RevokeAllForUser(ctx context.Context, userID, exceptID string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", &now).Error
+}
+
+func (r SessionRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at <= NOW()").Delete(&models.Session{}).Error
+}
@@ -49,6 +49,19 @@ func (r StoryRepository) GetStoriesByUser(ctx context.Context, userID string) ([
 	return stories, nil
 }
 
+// GetStoriesForExport lists every story userID posted, trashed ones
+// included, for their data export.
+func (r StoryRepository) GetStoriesForExport(ctx context.Context, userID string) ([]models.Story, error) {
+	var stories []models.Story
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&stories).Error; err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
+
 func (r StoryRepository) GetRecentStoriesByUser(ctx context.Context, userID string) ([]models.Story, error) {
 	var stories []models.Story
 	timeLimit := time.Now().Add(-24 * time.Hour)
@@ -109,8 +122,9 @@ func (r StoryRepository) DeleteStoryByUser(ctx context.Context, storyID, userID
 
 func (r StoryRepository) DeleteExpiredStories(ctx context.Context, hoursLimit int) error {
 	timeLimit := time.Now().Add(-time.Duration(hoursLimit) * time.Hour)
-	return r.db.WithContext(ctx).
-		Where("created_at < ?", timeLimit).
+	// Stories sitting in the trash are left for the trash purge.
+	return r.db.WithContext(ctx).Unscoped().
+		Where("created_at < ? AND deleted_at IS NULL", timeLimit).
 		Delete(&models.Story{}).Error
 }
 
@@ -122,7 +136,7 @@ func (r StoryRepository) GetFollowedUsersWithStories(ctx context.Context, userID
 		Distinct("users.*").
 		Joins("JOIN follows ON follows.following_id = users.id").
 		Where("follows.follower_id = ?", userID).
-		Where("EXISTS (SELECT 1 FROM stories WHERE stories.user_id = users.id AND stories.created_at > ?)", timeLimit).
+		Where("EXISTS (SELECT 1 FROM stories WHERE stories.user_id = users.id AND stories.created_at > ? AND stories.deleted_at IS NULL)", timeLimit).
 		Preload("Stories", "created_at > ?", timeLimit, func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at ASC")
 		}).
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"modern-social-media/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TrashRetention is how long deleted posts, comments and stories can be
+// restored before the purge removes them for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+type TrashRepository struct {
+	db *gorm.DB
+}
+
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Preview   string    `json:"preview"`
+	MediaURL  string    `json:"media_url,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// List returns the user's restorable items, most recently deleted first.
+func (r TrashRepository) List(ctx context.Context, userID string) ([]TrashItem, error) {
+	var items []TrashItem
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT 'post' AS type, id, content AS preview, image_url AS media_url, deleted_at
+		FROM posts WHERE user_id = @user AND deleted_at > @since
+		UNION ALL
+		SELECT 'comment', id, message, image_url, deleted_at
+		FROM comments WHERE user_id = @user AND deleted_at > @since
+		UNION ALL
+		SELECT 'story', id, '', media_url, deleted_at
+		FROM stories WHERE user_id = @user AND deleted_at > @since
+		ORDER BY deleted_at DESC, id DESC`, map[string]interface{}{
+		"user":  userID,
+		"since": time.Now().Add(-TrashRetention),
+	}).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].ExpiresAt = items[i].DeletedAt.Add(TrashRetention)
+	}
+	return items, nil
+}
+
+// Restore brings a trashed item back. A comment only comes back while its
+// post is live, and a story only while it is still within its 24 hours.
+func (r TrashRepository) Restore(ctx context.Context, userID, kind, id string) error {
+	since := time.Now().Add(-TrashRetention)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at > ?", id, userID, since)
+		switch kind {
+		case "post":
+			return restoreRow(q.Model(&models.Post{}))
+		case "comment":
+			var comment models.Comment
+			if err := q.Where(onLivePost).First(&comment).Error; err != nil {
+				return err
+			}
+			if err := restoreRow(tx.Unscoped().Model(&comment)); err != nil {
+				return err
+			}
+			return recountComments(tx, comment.PostID)
+		case "story":
+			var story models.Story
+			if err := q.First(&story).Error; err != nil {
+				return err
+			}
+			if story.CreatedAt.Before(time.Now().Add(-24 * time.Hour)) {
+				return errors.New("story_expired")
+			}
+			return restoreRow(tx.Unscoped().Model(&story))
+		default:
+			return errors.New("invalid_trash_type")
+		}
+	})
+}
+
+func restoreRow(q *gorm.DB) error {
+	res := q.UpdateColumn("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeExpired hard-deletes everything that has been in the trash longer
+// than TrashRetention, along with the attachments, revisions, tags,
+// mentions, likes and comments of purged posts. It returns upload URLs nothing references
+// anymore.
+func (r TrashRepository) PurgeExpired(ctx context.Context, now time.Time) ([]string, error) {
+	before := now.Add(-TrashRetention)
+	var orphaned []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expiredPosts := tx.Unscoped().Model(&models.Post{}).Select("id").Where("deleted_at <= ?", before)
+		expiredStories := tx.Unscoped().Model(&models.Story{}).Select("id").Where("deleted_at <= ?", before)
+		expiredComments := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("deleted_at <= ? OR post_id IN (?)", before, expiredPosts)
+
+		var media []string
+		if err := tx.Raw(`SELECT image_url FROM posts WHERE deleted_at <= @before AND image_url <> ''
+			UNION SELECT url FROM post_media WHERE post_id IN (SELECT id FROM posts WHERE deleted_at <= @before)
+			UNION SELECT image_url FROM post_revisions WHERE post_id IN (SELECT id FROM posts WHERE deleted_at <= @before) AND image_url <> ''
+			UNION SELECT m->>'url' FROM post_revisions r, jsonb_array_elements(r.media) m WHERE r.post_id IN (SELECT id FROM posts WHERE deleted_at <= @before)
+			UNION SELECT image_url FROM comments WHERE (deleted_at <= @before OR post_id IN (SELECT id FROM posts WHERE deleted_at <= @before)) AND image_url <> ''
+			UNION SELECT media_url FROM stories WHERE deleted_at <= @before`,
+			map[string]interface{}{"before": before}).Scan(&media).Error; err != nil {
+			return err
+		}
+
+		deletes := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.Mention{}, "(source_type = 'post' AND source_id IN (?)) OR (source_type = 'comment' AND source_id IN (?))", []interface{}{expiredPosts, expiredComments}},
+			{&models.PostMedia{}, "post_id IN (?)", []interface{}{expiredPosts}},
+			{&models.PostRevision{}, "post_id IN (?)", []interface{}{expiredPosts}},
+			{&models.PostHashtag{}, "post_id IN (?)", []interface{}{expiredPosts}},
+			{&models.Like{}, "post_id IN (?) OR story_id IN (?)", []interface{}{expiredPosts, expiredStories}},
+			{&models.Repost{}, "post_id IN (?)", []interface{}{expiredPosts}},
+			{&models.Bookmark{}, "post_id IN (?)", []interface{}{expiredPosts}},
+			{&models.Comment{}, "deleted_at <= ? OR post_id IN (?)", []interface{}{before, expiredPosts}},
+			{&models.Post{}, "deleted_at <= ?", []interface{}{before}},
+			{&models.Story{}, "deleted_at <= ?", []interface{}{before}},
+		}
+		for _, d := range deletes {
+			if err := tx.Unscoped().Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		orphaned, err = unreferencedUploads(tx, media)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
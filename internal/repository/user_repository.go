@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"modern-social-media/internal/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -51,8 +54,39 @@ func (r UserRepository) UpdateUser(ctx context.Context, u *models.User) error {
 	return r.db.WithContext(ctx).Save(u).Error
 }
 
-func (r UserRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error
+// ChangeEmail consumes the confirmation code and sets the new address in one
+// transaction: a failed update leaves the code usable, and a code already
+// consumed by a concurrent request changes nothing. It relies on the unique
+// index rather than a prior lookup, so two accounts racing for the same
+// address can't both win.
+func (r UserRepository) ChangeEmail(ctx context.Context, id, codeID, email string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.VerificationCode{}).
+			Where("id = ? AND user_id = ? AND consumed_at IS NULL", codeID, id).
+			Update("consumed_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("invalid_code")
+		}
+		err := tx.Model(&models.User{}).Where("id = ?", id).Update("email", email).Error
+		if isUniqueViolation(err) {
+			return errors.New("email_in_use")
+		}
+		return err
+	})
+}
+
+// AdvanceTOTPCounter records counter as the user's last used TOTP step if it
+// is newer than the stored one. It reports false when another request has
+// already used this or a later step, so a code can't be replayed even by
+// two concurrent logins.
+func (r UserRepository) AdvanceTOTPCounter(ctx context.Context, id string, counter int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		UpdateColumn("totp_last_counter", counter)
+	return res.RowsAffected == 1, res.Error
 }
 
 func (r UserRepository) GetFollowersCount(ctx context.Context, userID string) (int64, error) {
@@ -66,3 +100,110 @@ func (r UserRepository) GetFollowingCount(ctx context.Context, userID string) (i
 	err := r.db.WithContext(ctx).Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&count).Error
 	return count, err
 }
+
+type UserSearchResult struct {
+	models.User
+	Score       float64
+	IsFollowing bool
+	FollowsYou  bool
+}
+
+// UserSearchCursor is the (score, id) of the last row of the previous page.
+type UserSearchCursor struct {
+	Score float64 `json:"s"`
+	ID    string  `json:"id"`
+}
+
+// Search ranks active users by trigram similarity of username and name
+// against q. Accounts the viewer follows, or that follow the viewer, get a
+// fixed boost so they surface above strangers with a similar score.
+func (r UserRepository) Search(ctx context.Context, viewerID, q string, after *UserSearchCursor, limit int) ([]UserSearchResult, error) {
+	params := map[string]interface{}{
+		"q":      q,
+		"prefix": escapeLike(q) + "%",
+		"viewer": viewerID,
+		"limit":  limit,
+	}
+	cursorSQL := ""
+	if after != nil {
+		cursorSQL = "WHERE s.score < @cs OR (s.score = @cs AND s.id > @cid)"
+		params["cs"] = after.Score
+		params["cid"] = after.ID
+	}
+
+	var results []UserSearchResult
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT s.* FROM (
+			SELECT u.*,
+				fo.follower_id IS NOT NULL AS is_following,
+				fb.follower_id IS NOT NULL AS follows_you,
+				(GREATEST(
+					similarity(u.username, @q),
+					similarity(u.first_name, @q),
+					similarity(u.last_name, @q),
+					similarity(u.first_name || ' ' || u.last_name, @q)
+				)::float8
+				+ CASE WHEN u.username ILIKE @prefix THEN 0.2 ELSE 0 END
+				+ CASE WHEN fo.follower_id IS NOT NULL THEN 0.3 ELSE 0 END
+				+ CASE WHEN fb.follower_id IS NOT NULL THEN 0.15 ELSE 0 END)::float8 AS score
+			FROM users u
+			LEFT JOIN follows fo ON fo.follower_id = @viewer AND fo.following_id = u.id
+			LEFT JOIN follows fb ON fb.follower_id = u.id AND fb.following_id = @viewer
+			WHERE u.is_active
+				AND (u.username % @q OR u.first_name % @q OR u.last_name % @q OR u.username ILIKE @prefix)
+		) s
+		`+cursorSQL+`
+		ORDER BY s.score DESC, s.id ASC
+		LIMIT @limit`, params).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r UserRepository) CountByRole(ctx context.Context, role models.Role) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+// SetRole changes the user's role and writes the audit record in the same
+// transaction. Demoting the only remaining admin is refused.
+func (r UserRepository) SetRole(ctx context.Context, userID string, role models.Role, actorID *string, reason string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Role == role {
+			return nil
+		}
+		if user.Role == models.RoleAdmin {
+			var adminIDs []string
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.User{}).
+				Where("role = ?", models.RoleAdmin).Pluck("id", &adminIDs).Error; err != nil {
+				return err
+			}
+			if len(adminIDs) <= 1 {
+				return errors.New("last_admin")
+			}
+		}
+
+		change := &models.RoleChange{
+			UserID:  user.ID,
+			ActorID: actorID,
+			OldRole: user.Role,
+			NewRole: role,
+			Reason:  reason,
+		}
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+		user.Role = role
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -17,10 +17,11 @@ func (r VerificationCodeRepository) Create(ctx context.Context, v *models.Verifi
 	return r.db.WithContext(ctx).Create(v).Error
 }
 
-func (r VerificationCodeRepository) GetValid(ctx context.Context, userID, purpose, code string) (*models.VerificationCode, error) {
+func (r VerificationCodeRepository) GetActive(ctx context.Context, userID, purpose string) (*models.VerificationCode, error) {
 	var v models.VerificationCode
 	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND purpose = ? AND code = ? AND expires_at > NOW() AND consumed_at IS NULL", userID, purpose, code).
+		Where("user_id = ? AND purpose = ? AND expires_at > NOW() AND consumed_at IS NULL", userID, purpose).
+		Order("created_at DESC").
 		First(&v).Error
 	if err != nil {
 		return nil, err
@@ -28,6 +29,14 @@ func (r VerificationCodeRepository) GetValid(ctx context.Context, userID, purpos
 	return &v, nil
 }
 
+func (r VerificationCodeRepository) IncrementAttempts(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).
+		Raw("UPDATE verification_codes SET attempts = attempts + 1 WHERE id = ? RETURNING attempts", id).
+		Scan(&attempts).Error
+	return attempts, err
+}
+
 func (r VerificationCodeRepository) Consume(ctx context.Context, id string) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).Model(&models.VerificationCode{}).
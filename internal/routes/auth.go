@@ -2,30 +2,75 @@ package routes
 
 import (
 	authhandlers "modern-social-media/internal/handlers/auth"
+	"modern-social-media/internal/middleware"
 	"modern-social-media/internal/services"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterAuthRoutes(rg *gin.RouterGroup, d Deps) {
-	svc := services.AuthService{
-		Users:           d.Models.Users,
-		Codes:           d.Models.VerificationCodes,
-		Tokens:          &services.JWTTokenService{Secret: []byte(d.JWTSecret), AccessTTL: 15 * time.Minute, RefreshTTL: 14 * 24 * time.Hour},
+// newAuthService builds the AuthService shared by the auth and /user/me
+// routes. Limiter state lives in the database, so every copy sees the same
+// lockouts.
+func newAuthService(d Deps) services.AuthService {
+	return services.AuthService{
+		Users:         d.Models.Users,
+		Codes:         d.Models.VerificationCodes,
+		Tokens:        &services.JWTTokenService{Keys: d.Keys, AccessTTL: 15 * time.Minute, RefreshTTL: 14 * 24 * time.Hour},
+		RefreshTokens: d.Models.RefreshTokens,
+		Sessions:      d.Models.Sessions,
+		RecoveryCodes: d.Models.RecoveryCodes,
+		Deletions:     d.Models.Users,
+		Limiter: &services.AttemptLimiter{
+			Store:   d.Models.LoginAttempts,
+			Clock:   services.RealClock{},
+			Account: services.AttemptPolicy{FreeAttempts: 5, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, Window: time.Hour},
+			IP:      services.AttemptPolicy{FreeAttempts: 20, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, Window: time.Hour},
+		},
 		Mailer:          d.Mailer,
 		Hasher:          services.Argon2Hasher{},
 		Clock:           services.RealClock{},
 		Transact:        services.NoopTxRunner{},
 		Email2FAEnabled: d.Email2FAEnabled,
+		TOTPIssuer:      "Modern Social",
 	}
+}
+
+func RegisterAuthRoutes(rg *gin.RouterGroup, d Deps) {
+	svc := newAuthService(d)
 
 	rg.POST("/auth/register", authhandlers.RegisterWithService(svc))
 	rg.POST("/auth/login", authhandlers.LoginWithService(svc))
-	rg.POST("/auth/refresh", authhandlers.Refresh(d.JWTSecret))
+	rg.POST("/auth/refresh", authhandlers.Refresh(svc))
 	rg.POST("/auth/verify-email", authhandlers.VerifyEmailWithService(svc))
 	rg.POST("/auth/resend-verify-email", authhandlers.ResendVerificationEmailWithService(svc))
 	rg.POST("/auth/2fa/verify", authhandlers.VerifyLogin2FAWithService(svc))
 	rg.POST("/auth/2fa/request", authhandlers.Request2FACodeWithService(svc))
-	rg.POST("/auth/toggle-2fa", authhandlers.Toggle2FAWithService(svc, d.JWTSecret))
+	rg.POST("/auth/password/forgot", authhandlers.ForgotPasswordWithService(svc))
+	rg.POST("/auth/password/reset", authhandlers.ResetPasswordWithService(svc))
+	rg.POST("/auth/account/restore", authhandlers.RestoreAccountWithService(svc))
+	rg.POST("/auth/toggle-2fa", middleware.Auth(d.Keys, d.Models.Sessions), authhandlers.Toggle2FAWithService(svc))
+
+	totp := rg.Group("/auth/2fa/totp")
+	totp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+	{
+		totp.POST("/enroll", authhandlers.EnrollTOTPWithService(svc))
+		totp.POST("/confirm", authhandlers.ConfirmTOTPWithService(svc))
+		totp.POST("/disable", authhandlers.DisableTOTPWithService(svc))
+	}
+
+	recovery := rg.Group("/auth/2fa/recovery-codes")
+	recovery.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+	{
+		recovery.GET("", authhandlers.RecoveryCodesStatusWithService(svc))
+		recovery.POST("/regenerate", authhandlers.RegenerateRecoveryCodesWithService(svc))
+	}
+
+	sessions := rg.Group("/auth/sessions")
+	sessions.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+	{
+		sessions.GET("", authhandlers.ListSessionsWithService(svc))
+		sessions.DELETE("/:id", authhandlers.RevokeSessionWithService(svc))
+		sessions.POST("/revoke-others", authhandlers.RevokeOtherSessionsWithService(svc))
+	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"modern-social-media/internal/handlers"
+	"modern-social-media/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterBookmarkRoutes(rg *gin.RouterGroup, d Deps) {
+	grp := rg.Group("/bookmarks")
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+
+	grp.GET("", handlers.GetBookmarks(d.Models.Bookmarks))
+	grp.PATCH("/:post_id", handlers.MoveBookmark(d.Models.Bookmarks))
+
+	grp.GET("/collections", handlers.GetCollections(d.Models.Bookmarks))
+	grp.POST("/collections", handlers.CreateCollection(d.Models.Bookmarks))
+	grp.PATCH("/collections/:id", handlers.RenameCollection(d.Models.Bookmarks))
+}
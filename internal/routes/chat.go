@@ -8,14 +8,14 @@ import (
 )
 
 func RegisterChatRoutes(rg *gin.RouterGroup, d Deps, hub *handlers.Hub) {
-	rg.GET("/ws", handlers.ChatWSHandler(handlers.ChatWSDeps{Models: d.Models, JWTSecret: d.JWTSecret, Hub: hub}))
+	rg.GET("/ws", handlers.ChatWSHandler(handlers.ChatWSDeps{Models: d.Models, Keys: d.Keys, Hub: hub, Mentions: d.Mentions}))
 
 	chat := rg.Group("/chat")
-	chat.Use(middleware.Auth(d.JWTSecret))
+	chat.Use(middleware.Auth(d.Keys, d.Models.Sessions))
 	{
 		chat.GET("/conversations", handlers.ListConversations(d.Models))
 		chat.GET("/conversations/:id/messages", handlers.ListMessages(d.Models))
-		chat.POST("/direct/:user_id/send", handlers.SendDirectMessage(d.Models, hub))
+		chat.POST("/direct/:user_id/send", handlers.SendDirectMessage(d.Models, hub, d.Mentions))
 		chat.POST("/conversations/:id/read", handlers.MarkRead(d.Models))
 		chat.GET("/presence/:user_id", handlers.GetPresence(hub))
 	}
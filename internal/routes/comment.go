@@ -8,11 +8,13 @@ import (
 )
 
 func RegisterCommentsRoutes(rg *gin.RouterGroup, d Deps) {
-	rg.GET("/comment/post/:id", handlers.GetCommentsByPost(d.Models.Comments))
+	rg.GET("/comment/post/:id", middleware.OptionalAuth(d.Keys, d.Models.Sessions), handlers.GetCommentsByPost(d.Models.Comments))
 
-	rg.GET("/comment/user/", middleware.Auth(d.JWTSecret), handlers.GetCommentsByUser(d.Models.Comments))
+	rg.GET("/comment/user/", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetCommentsByUser(d.Models.Comments))
 
-	rg.GET("/comment/:id", handlers.GetCommentById(d.Models.Comments))
+	rg.GET("/comment/:id", middleware.OptionalAuth(d.Keys, d.Models.Sessions), handlers.GetCommentById(d.Models.Comments))
 
-	rg.POST("/comment/post/:id", middleware.Auth(d.JWTSecret), handlers.CreateComment(d.Models.Comments))
+	rg.POST("/comment/post/:id", middleware.Auth(d.Keys, d.Models.Sessions), handlers.CreateComment(d.Models.Comments, d.Mentions))
+
+	rg.DELETE("/comment/:id", middleware.Auth(d.Keys, d.Models.Sessions), handlers.DeleteComment(d.Models.Comments))
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"modern-social-media/internal/handlers"
+	"modern-social-media/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDataExportRoutes(rg *gin.RouterGroup, d Deps) {
+	grp := rg.Group("/user/me/export")
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+
+	grp.POST("", handlers.RequestDataExport(d.Models.DataExports))
+	grp.GET("", handlers.ListDataExports(d.Models.DataExports))
+	grp.GET("/:id/download", handlers.DownloadDataExport(d.Models.DataExports))
+
+	rg.GET("/exports/:id/download", handlers.DownloadDataExportByToken(d.Models.DataExports))
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"modern-social-media/internal/auth"
 	"modern-social-media/internal/repository"
 	"modern-social-media/internal/services"
 )
@@ -8,7 +9,9 @@ import (
 type Deps struct {
 	Models          repository.Models
 	Mailer          services.EmailSender
-	JWTSecret       string
-	AdminToken      string
+	Keys            *auth.KeyManager
+	FeedRanker      *services.FeedRanker
+	Mentions        *services.MentionService
+	Reposts         *services.RepostService
 	Email2FAEnabled bool
 }
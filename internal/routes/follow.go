@@ -9,12 +9,16 @@ import (
 
 func RegisterFollowRoutes(rg *gin.RouterGroup, d Deps) {
 	grp := rg.Group("/follow")
-	grp.Use(middleware.Auth(d.JWTSecret))
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
 	grp.POST("/:id/toggle", handlers.ToggleFollowWithNotification(d.Models.Follows, d.Models.Notifications))
 	grp.POST("/:id", handlers.FollowWithNotification(d.Models.Follows, d.Models.Notifications))
 	grp.DELETE("/:id", handlers.UnfollowWithNotification(d.Models.Follows, d.Models.Notifications))
 	grp.GET("/:id/status", handlers.IsFollowing(d.Models.Follows))
 
+	grp.GET("/close-friends", handlers.GetCloseFriends(d.Models.Follows))
+	grp.PUT("/close-friends/:id", handlers.SetCloseFriend(d.Models.Follows, true))
+	grp.DELETE("/close-friends/:id", handlers.SetCloseFriend(d.Models.Follows, false))
+
 	rg.GET("/user/:id/followers", handlers.GetFollowers(d.Models.Follows))
 	rg.GET("/user/:id/following", handlers.GetFollowing(d.Models.Follows))
 	rg.GET("/user/:id/follow-counts", handlers.GetFollowCounts(d.Models.Follows))
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"modern-social-media/internal/handlers"
+	"modern-social-media/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHashtagRoutes(rg *gin.RouterGroup, d Deps) {
+	grp := rg.Group("/tags")
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+
+	grp.GET("/trending", handlers.GetTrendingHashtags(d.Models.Hashtags))
+	grp.GET("/:tag/posts", handlers.GetHashtagPosts(d.Models.Hashtags, d.Models.Posts))
+}
@@ -9,7 +9,7 @@ import (
 
 func RegisterNotificationRoutes(rg *gin.RouterGroup, d Deps) {
 	grp := rg.Group("/notifications")
-	grp.Use(middleware.Auth(d.JWTSecret))
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
 
 	grp.GET("", handlers.GetNotifications(d.Models.Notifications))
 	grp.GET("/unread", handlers.GetUnreadNotifications(d.Models.Notifications))
@@ -8,15 +8,30 @@ import (
 )
 
 func RegisterPostRoutes(rg *gin.RouterGroup, d Deps) {
-	rg.GET("/post", middleware.Auth(d.JWTSecret), handlers.GetPostsByUser(d.Models.Posts))
+	rg.GET("/post", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetPostsByUser(d.Models.Posts))
 
-	rg.GET("/post/all", middleware.Auth(d.JWTSecret), handlers.GetAllPosts(d.Models.Posts))
+	rg.GET("/feed", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetFeed(d.Models.Posts))
+	rg.GET("/feed/for-you", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetForYouFeed(d.FeedRanker))
 
-	rg.POST("/post", middleware.Auth(d.JWTSecret), handlers.CreatePost(d.Models.Posts))
+	rg.GET("/post/all", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetAllPosts(d.Models.Posts))
 
-	rg.PUT("/post/:id", middleware.Auth(d.JWTSecret), handlers.UpdatePost(d.Models.Posts))
+	rg.POST("/post", middleware.Auth(d.Keys, d.Models.Sessions), handlers.CreatePost(d.Models.Posts, d.Mentions, d.Reposts))
 
-	rg.DELETE("/post/:id", middleware.Auth(d.JWTSecret), handlers.DeletePostByUser(d.Models.Posts))
+	rg.PUT("/post/:id", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UpdatePost(d.Models.Posts, d.Models.Bookmarks, d.Mentions))
 
-	rg.POST("/post/:id/like", middleware.Auth(d.JWTSecret), handlers.TogglePostLike(d.Models.Likes))
+	rg.PATCH("/post/:id/audience", middleware.Auth(d.Keys, d.Models.Sessions), handlers.SetPostAudience(d.Models.Posts, d.Models.Bookmarks, d.Mentions))
+
+	rg.GET("/post/:id/revisions", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetPostRevisions(d.Models.Posts))
+
+	rg.POST("/post/:id/revisions/:number/restore", middleware.Auth(d.Keys, d.Models.Sessions), handlers.RestorePostRevision(d.Models.Posts, d.Models.Bookmarks, d.Mentions))
+
+	rg.DELETE("/post/:id", middleware.Auth(d.Keys, d.Models.Sessions), handlers.DeletePostByUser(d.Models.Posts))
+
+	rg.POST("/post/:id/like", middleware.Auth(d.Keys, d.Models.Sessions), handlers.TogglePostLike(d.Models.Likes))
+
+	rg.POST("/post/:id/repost", middleware.Auth(d.Keys, d.Models.Sessions), handlers.RepostPost(d.Reposts))
+	rg.DELETE("/post/:id/repost", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UnrepostPost(d.Reposts))
+
+	rg.POST("/post/:id/bookmark", middleware.Auth(d.Keys, d.Models.Sessions), handlers.BookmarkPost(d.Models.Bookmarks))
+	rg.DELETE("/post/:id/bookmark", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UnbookmarkPost(d.Models.Bookmarks))
 }
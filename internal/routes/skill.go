@@ -8,7 +8,7 @@ import (
 )
 
 func RegisterSkillRoutes(rg *gin.RouterGroup, d Deps) {
-	rg.GET("/skill", middleware.Auth(d.JWTSecret), handlers.GetAllSkills(d.Models.Skills))
+	rg.GET("/skill", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetAllSkills(d.Models.Skills))
 
-	rg.POST("/skill", middleware.Auth(d.JWTSecret), handlers.AddSkill(d.Models.Skills))
+	rg.POST("/skill", middleware.Auth(d.Keys, d.Models.Sessions), handlers.AddSkill(d.Models.Skills))
 }
@@ -12,7 +12,7 @@ func RegisterStoryRoutes(rg *gin.RouterGroup, d Deps) {
 	rg.GET("/story/user/:id", handlers.GetStoriesByUserId(d.Models.Stories))
 
 	stories := rg.Group("/story")
-	stories.Use(middleware.Auth(d.JWTSecret))
+	stories.Use(middleware.Auth(d.Keys, d.Models.Sessions))
 	{
 		stories.GET("", handlers.GetStoriesByUser(d.Models.Stories))
 		stories.POST("", handlers.CreateStory(d.Models.Stories))
@@ -22,5 +22,5 @@ func RegisterStoryRoutes(rg *gin.RouterGroup, d Deps) {
 		stories.POST("/:id/like", handlers.ToggleStoryLike(d.Models.Likes))
 	}
 
-	rg.GET("/story/following", middleware.Auth(d.JWTSecret), handlers.GetAllStories(d.Models.Stories))
+	rg.GET("/story/following", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetAllStories(d.Models.Stories))
 }
\ No newline at end of file
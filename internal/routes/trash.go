@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"modern-social-media/internal/handlers"
+	"modern-social-media/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTrashRoutes(rg *gin.RouterGroup, d Deps) {
+	grp := rg.Group("/trash")
+	grp.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+
+	grp.GET("", handlers.GetTrash(d.Models.Trash))
+	grp.POST("/:type/:id/restore", handlers.RestoreTrashItem(d.Models.Trash))
+}
@@ -2,23 +2,42 @@ package routes
 
 import (
 	"modern-social-media/internal/handlers"
+	authhandlers "modern-social-media/internal/handlers/auth"
 	"modern-social-media/internal/middleware"
+	"modern-social-media/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterUserRoutes(rg *gin.RouterGroup, d Deps) {
-	rg.GET("/user/me", middleware.Auth(d.JWTSecret), handlers.GetCurrentUser(d.Models.Users))
-	rg.GET("/user/me/followers", middleware.Auth(d.JWTSecret), handlers.GetMyFollowers(d.Models.Follows))
-	rg.GET("/user/me/following", middleware.Auth(d.JWTSecret), handlers.GetMyFollowing(d.Models.Follows))
-	rg.GET("/user/by-email/:email", handlers.GetUserByEmail(d.Models.Users))
+	svc := newAuthService(d)
 
-	protected := rg.Group("", middleware.StaticToken(d.AdminToken))
+	rg.GET("/user/me", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetCurrentUser(d.Models.Users))
+	rg.PATCH("/user/me", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UpdateCurrentUser(d.Models.Users))
+	rg.DELETE("/user/me", middleware.Auth(d.Keys, d.Models.Sessions), authhandlers.DeleteAccountWithService(svc))
+	rg.POST("/user/me/password", middleware.Auth(d.Keys, d.Models.Sessions), handlers.ChangeCurrentUserPassword(svc, d.Models.Users))
+	rg.POST("/user/me/avatar", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UploadAvatar(d.Models.Users))
+	rg.POST("/user/me/cover", middleware.Auth(d.Keys, d.Models.Sessions), handlers.UploadCover(d.Models.Users))
+	rg.GET("/user/me/followers", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetMyFollowers(d.Models.Follows))
+	rg.GET("/user/me/following", middleware.Auth(d.Keys, d.Models.Sessions), handlers.GetMyFollowing(d.Models.Follows))
+
+	email := rg.Group("/user/me/email")
+	email.Use(middleware.Auth(d.Keys, d.Models.Sessions))
+	{
+		email.POST("", authhandlers.RequestEmailChangeWithService(svc))
+		email.POST("/confirm", authhandlers.ConfirmEmailChangeWithService(svc))
+	}
+
+	rg.GET("/user/search", middleware.OptionalAuth(d.Keys, d.Models.Sessions), handlers.SearchUsers(d.Models.Users))
+	rg.GET("/user/u/:username", middleware.OptionalAuth(d.Keys, d.Models.Sessions), handlers.GetPublicProfile(d.Models.Users, d.Models.Posts, d.Models.Follows, d.Models.Skills))
+
+	admin := rg.Group("", middleware.Auth(d.Keys, d.Models.Sessions))
 	{
-		protected.GET("/user", handlers.GetAllUsers(d.Models.Users))
-		protected.GET("/user/:id", handlers.GetUserById(d.Models.Users))
-		protected.POST("/user", handlers.CreateUser(d.Models.Users))
-		protected.PUT("/user/:id", handlers.UpdateUser(d.Models.Users))
-		protected.DELETE("/user/:id", handlers.DeleteUser(d.Models.Users))
+		admin.GET("/user", middleware.RequirePermission(models.PermUsersRead), handlers.GetAllUsers(d.Models.Users))
+		admin.GET("/user/:id", middleware.RequirePermission(models.PermUsersRead), handlers.GetUserById(d.Models.Users))
+		admin.POST("/user", middleware.RequirePermission(models.PermUsersWrite), handlers.CreateUser(d.Models.Users))
+		admin.PUT("/user/:id", middleware.RequirePermission(models.PermUsersWrite), handlers.UpdateUser(d.Models.Users))
+		admin.PUT("/user/:id/role", middleware.RequirePermission(models.PermRolesManage), handlers.SetUserRole(d.Models.Users))
+		admin.DELETE("/user/:id", middleware.RequirePermission(models.PermUsersDelete), handlers.DeleteUser(d.Models.Users, d.Models.Sessions, d.Models.RefreshTokens))
 	}
 }
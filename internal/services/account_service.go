@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+)
+
+const (
+	accountDeletionPurpose = "account_deletion"
+	accountDeletionGrace   = 30 * 24 * time.Hour
+)
+
+type AccountDeletionRepo interface {
+	ScheduleDeletion(ctx context.Context, id string, at time.Time) error
+	CancelDeletion(ctx context.Context, id string) (bool, error)
+}
+
+// RequestAccountDeletion deactivates the account immediately and signs out
+// every session. The data stays intact until the grace period runs out.
+func (s *AuthService) RequestAccountDeletion(ctx context.Context, userID, password string, meta ClientMeta) (time.Time, error) {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, errors.New("not_found")
+	}
+	if err := s.Limiter.Check(ctx, accountDeletionPurpose, u.Email, meta.IP); err != nil {
+		return time.Time{}, err
+	}
+	ok, err := s.Hasher.Verify(u.Password, strings.TrimSpace(password))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		if err := s.Limiter.Fail(ctx, accountDeletionPurpose, u.Email, meta.IP); err != nil {
+			return time.Time{}, err
+		}
+		return time.Time{}, errors.New("invalid_credentials")
+	}
+	at := s.Clock.Now().Add(accountDeletionGrace)
+	if err := s.Deletions.ScheduleDeletion(ctx, u.ID, at); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.Sessions.RevokeAllForUser(ctx, u.ID, ""); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.RefreshTokens.RevokeAllForUser(ctx, u.ID, ""); err != nil {
+		return time.Time{}, err
+	}
+	_ = s.Mailer.Send(u.Email, "Удаление аккаунта", "Ваш аккаунт будет удалён "+at.Format("02.01.2006")+". До этого момента вы можете отменить удаление, войдя с паролем через восстановление аккаунта.")
+	return at, nil
+}
+
+// RestoreAccount cancels a pending deletion. It checks the password the same
+// way Login does because the account can't hold a session while deactivated.
+func (s *AuthService) RestoreAccount(ctx context.Context, email, password string, meta ClientMeta) error {
+	email = utils.NormalizeEmail(email)
+	if err := s.Limiter.Check(ctx, "login", email, meta.IP); err != nil {
+		return err
+	}
+	u, err := s.Users.GetByEmail(ctx, email)
+	if err == nil {
+		var ok bool
+		ok, err = s.Hasher.Verify(u.Password, strings.TrimSpace(password))
+		if err == nil && !ok {
+			err = errors.New("invalid_credentials")
+		}
+	}
+	if err != nil {
+		if err := s.Limiter.Fail(ctx, "login", email, meta.IP); err != nil {
+			return err
+		}
+		return errors.New("invalid_credentials")
+	}
+	if err := s.Limiter.Succeed(ctx, "login", email); err != nil {
+		return err
+	}
+	restored, err := s.Deletions.CancelDeletion(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return errors.New("not_pending_deletion")
+	}
+	return nil
+}
+
+type AccountPurgeService struct {
+	Repo repository.UserRepository
+}
+
+func NewAccountPurgeService(repo repository.UserRepository) *AccountPurgeService {
+	return &AccountPurgeService{Repo: repo}
+}
+
+func (s *AccountPurgeService) PurgeDueAccounts(ctx context.Context) error {
+	ids, err := s.Repo.ListDueForPurge(ctx, time.Now(), 50)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts due for purge: %w", err)
+	}
+
+	for _, id := range ids {
+		orphaned, err := s.Repo.Purge(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to purge account %s: %w", id, err)
+		}
+		for _, url := range orphaned {
+			utils.RemoveUpload(url)
+		}
+		for _, dir := range []string{"avatars", "covers"} {
+			if err := os.RemoveAll(filepath.Join("uploads", dir, id)); err != nil {
+				fmt.Printf("Warning: failed to remove %s uploads of %s: %v\n", dir, id, err)
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(DataExportDir, id)); err != nil {
+			fmt.Printf("Warning: failed to remove exports of %s: %v\n", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"modern-social-media/internal/models"
+)
+
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (*models.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return "too_many_attempts" }
+
+// AttemptPolicy allows FreeAttempts failures inside Window, then locks the key
+// for BaseLockout, doubling with every further failure up to MaxLockout.
+type AttemptPolicy struct {
+	FreeAttempts int
+	BaseLockout  time.Duration
+	MaxLockout   time.Duration
+	Window       time.Duration
+}
+
+func (p AttemptPolicy) lockoutFor(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := p.FreeAttempts + 1; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+	return lockout
+}
+
+type AttemptLimiter struct {
+	Store   AttemptStore
+	Clock   Clock
+	Account AttemptPolicy
+	IP      AttemptPolicy
+}
+
+func (l *AttemptLimiter) Check(ctx context.Context, scope, account, ip string) error {
+	if l == nil {
+		return nil
+	}
+	now := l.Clock.Now()
+	var retryAfter time.Duration
+	for _, k := range l.keys(scope, account, ip) {
+		a, err := l.Store.Get(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if a.LockedUntil != nil && a.LockedUntil.After(now) {
+			if wait := a.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (l *AttemptLimiter) Fail(ctx context.Context, scope, account, ip string) error {
+	if l == nil {
+		return nil
+	}
+	now := l.Clock.Now()
+	for _, k := range l.keys(scope, account, ip) {
+		failures, err := l.Store.RecordFailure(ctx, k.key, now, k.policy.Window)
+		if err != nil {
+			return err
+		}
+		if lockout := k.policy.lockoutFor(failures); lockout > 0 {
+			if err := l.Store.Lock(ctx, k.key, now.Add(lockout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *AttemptLimiter) Succeed(ctx context.Context, scope, account string) error {
+	if l == nil {
+		return nil
+	}
+	return l.Store.Reset(ctx, "acct:"+scope+":"+account)
+}
+
+type limitKey struct {
+	key    string
+	policy AttemptPolicy
+}
+
+func (l *AttemptLimiter) keys(scope, account, ip string) []limitKey {
+	keys := []limitKey{{key: "acct:" + scope + ":" + account, policy: l.Account}}
+	if ip != "" {
+		keys = append(keys, limitKey{key: "ip:" + ip, policy: l.IP})
+	}
+	return keys
+}
+
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (m *MemoryAttemptStore) Get(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.attempts[key]
+	if !ok {
+		return &models.LoginAttempt{Key: key}, nil
+	}
+	return &a, nil
+}
+
+func (m *MemoryAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.attempts[key]
+	a.Key = key
+	if a.LastFailureAt.Before(now.Add(-window)) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	m.attempts[key] = a
+	return a.Failures, nil
+}
+
+func (m *MemoryAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.attempts[key]
+	a.Key = key
+	a.LockedUntil = &until
+	m.attempts[key] = a
+	return nil
+}
+
+func (m *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestAttemptPolicyLockoutFor(t *testing.T) {
+	p := AttemptPolicy{FreeAttempts: 3, BaseLockout: time.Minute, MaxLockout: 8 * time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Minute},
+		{5, 2 * time.Minute},
+		{6, 4 * time.Minute},
+		{7, 8 * time.Minute},
+		{20, 8 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.lockoutFor(tt.failures); got != tt.want {
+			t.Errorf("lockoutFor(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestAttemptLimiter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	account := AttemptPolicy{FreeAttempts: 2, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute, Window: time.Hour}
+	ip := AttemptPolicy{FreeAttempts: 4, BaseLockout: 5 * time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+
+	type step struct {
+		advance time.Duration
+		fail    bool
+		succeed bool
+		ip      string
+		// wantRetry is the expected lockout reported by Check after the
+		// step; zero means Check must pass.
+		wantRetry time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "free attempts do not lock",
+			steps: []step{
+				{fail: true, ip: "1.1.1.1"},
+				{fail: true, ip: "1.1.1.1"},
+			},
+		},
+		{
+			name: "account locks after free attempts and doubles",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{fail: true, wantRetry: time.Minute},
+				{advance: 30 * time.Second, wantRetry: 30 * time.Second},
+				{advance: 30 * time.Second},
+				{fail: true, wantRetry: 2 * time.Minute},
+			},
+		},
+		{
+			name: "failures outside the window are forgotten",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{advance: 2 * time.Hour, fail: true},
+			},
+		},
+		{
+			name: "success resets the account key",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{succeed: true},
+				{fail: true},
+			},
+		},
+		{
+			name: "ip lock outlives a success on the account",
+			steps: []step{
+				{fail: true, ip: "2.2.2.2"},
+				{fail: true, ip: "2.2.2.2"},
+				{fail: true, ip: "2.2.2.2", wantRetry: time.Minute},
+				{fail: true, ip: "2.2.2.2", wantRetry: 2 * time.Minute},
+				{fail: true, ip: "2.2.2.2", wantRetry: 5 * time.Minute},
+				{succeed: true, ip: "2.2.2.2", wantRetry: 5 * time.Minute},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := &fakeClock{now: start}
+			l := &AttemptLimiter{Store: NewMemoryAttemptStore(), Clock: clock, Account: account, IP: ip}
+			for i, s := range tt.steps {
+				clock.now = clock.now.Add(s.advance)
+				if s.fail {
+					if err := l.Fail(ctx, "login", "a@example.com", s.ip); err != nil {
+						t.Fatalf("step %d: Fail: %v", i, err)
+					}
+				}
+				if s.succeed {
+					if err := l.Succeed(ctx, "login", "a@example.com"); err != nil {
+						t.Fatalf("step %d: Succeed: %v", i, err)
+					}
+				}
+				err := l.Check(ctx, "login", "a@example.com", s.ip)
+				if s.wantRetry == 0 {
+					if err != nil {
+						t.Fatalf("step %d: Check = %v, want nil", i, err)
+					}
+					continue
+				}
+				var rl *RateLimitError
+				if !errors.As(err, &rl) {
+					t.Fatalf("step %d: Check = %v, want RateLimitError", i, err)
+				}
+				if rl.RetryAfter != s.wantRetry {
+					t.Fatalf("step %d: RetryAfter = %v, want %v", i, rl.RetryAfter, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestAttemptLimiterScopesAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	l := &AttemptLimiter{
+		Store:   NewMemoryAttemptStore(),
+		Clock:   &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Account: AttemptPolicy{FreeAttempts: 0, BaseLockout: time.Minute, MaxLockout: time.Minute, Window: time.Hour},
+	}
+	if err := l.Fail(ctx, "login", "a@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(ctx, "login", "a@example.com", ""); err == nil {
+		t.Fatal("login scope should be locked")
+	}
+	if err := l.Check(ctx, "totp", "a@example.com", ""); err != nil {
+		t.Fatalf("totp scope should be open, got %v", err)
+	}
+}
+
+func TestNilAttemptLimiterAllowsEverything(t *testing.T) {
+	var l *AttemptLimiter
+	ctx := context.Background()
+	if err := l.Fail(ctx, "login", "a", "ip"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(ctx, "login", "a", "ip"); err != nil {
+		t.Fatal(err)
+	}
+}
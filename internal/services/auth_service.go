@@ -2,12 +2,16 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"strings"
 	"time"
 
+	"modern-social-media/internal/auth"
 	"modern-social-media/internal/models"
 	"modern-social-media/internal/utils"
+
+	"github.com/lucsky/cuid"
 )
 
 type UserRepo interface {
@@ -16,16 +20,44 @@ type UserRepo interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	CreateUser(ctx context.Context, u *models.User) error
 	UpdateUser(ctx context.Context, u *models.User) error
+	ChangeEmail(ctx context.Context, id, codeID, email string) error
+	AdvanceTOTPCounter(ctx context.Context, id string, counter int64) (bool, error)
 }
 
 type CodeRepo interface {
 	Create(ctx context.Context, v *models.VerificationCode) error
-	GetValid(ctx context.Context, userID, purpose, code string) (*models.VerificationCode, error)
+	GetActive(ctx context.Context, userID, purpose string) (*models.VerificationCode, error)
+	IncrementAttempts(ctx context.Context, id string) (int, error)
 	Consume(ctx context.Context, id string) error
 	DeleteByUserAndPurpose(ctx context.Context, userID, purpose string) error
 	DeleteExpired(ctx context.Context) error
 }
 
+type RefreshTokenRepo interface {
+	Create(ctx context.Context, t *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID, exceptFamilyID string) error
+}
+
+type SessionRepo interface {
+	Create(ctx context.Context, s *models.Session) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	ListActiveByUser(ctx context.Context, userID string) ([]models.Session, error)
+	Touch(ctx context.Context, id, userAgent, ip string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id, userID string) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID, exceptID string) error
+}
+
+type RecoveryCodeRepo interface {
+	ReplaceForUser(ctx context.Context, userID string, hashes []string) error
+	ListUnused(ctx context.Context, userID string) ([]models.RecoveryCode, error)
+	CountUnused(ctx context.Context, userID string) (int64, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
 type Mailer interface {
 	Send(to, subject, body string) error
 }
@@ -42,15 +74,23 @@ type RealClock struct{}
 
 func (RealClock) Now() time.Time { return time.Now() }
 
+const maxCodeAttempts = 5
+
 type AuthService struct {
 	Users           UserRepo
 	Codes           CodeRepo
 	Tokens          TokenService
+	RefreshTokens   RefreshTokenRepo
+	Sessions        SessionRepo
+	RecoveryCodes   RecoveryCodeRepo
+	Deletions       AccountDeletionRepo
+	Limiter         *AttemptLimiter
 	Mailer          Mailer
 	Hasher          PasswordHasher
 	Clock           Clock
 	Transact        TxRunner
 	Email2FAEnabled bool
+	TOTPIssuer      string
 }
 
 func (s *AuthService) Register(ctx context.Context, in RegisterInput) (*models.User, error) {
@@ -86,6 +126,7 @@ func (s *AuthService) Register(ctx context.Context, in RegisterInput) (*models.U
 			u.LastName = last
 			u.IsVerified = false
 			u.IsActive = true
+			u.DeletionScheduledAt = nil
 			if err := s.Users.UpdateUser(ctx, u); err != nil {
 				return nil, err
 			}
@@ -148,50 +189,78 @@ func (s *AuthService) Register(ctx context.Context, in RegisterInput) (*models.U
 	return created, nil
 }
 
-func (s *AuthService) VerifyEmail(ctx context.Context, email, code string) error {
+func (s *AuthService) VerifyEmail(ctx context.Context, email, code string, meta ClientMeta) error {
 	email = utils.NormalizeEmail(email)
 	code = strings.TrimSpace(code)
+	if err := s.Limiter.Check(ctx, "verify_email", email, meta.IP); err != nil {
+		return err
+	}
 	u, err := s.Users.GetByEmail(ctx, email)
 	if err != nil {
+		if err := s.Limiter.Fail(ctx, "verify_email", email, meta.IP); err != nil {
+			return err
+		}
 		return errors.New("not_found")
 	}
-	v, err := s.Codes.GetValid(ctx, u.ID, "email_verify", code)
+	v, err := s.checkCode(ctx, u.ID, "email_verify", code)
 	if err != nil {
-		return errors.New("invalid_code")
+		if err.Error() == "invalid_code" {
+			if err := s.Limiter.Fail(ctx, "verify_email", email, meta.IP); err != nil {
+				return err
+			}
+		}
+		return err
 	}
 	if err := s.Codes.Consume(ctx, v.ID); err != nil {
 		return err
 	}
+	if err := s.Limiter.Succeed(ctx, "verify_email", email); err != nil {
+		return err
+	}
 	u.IsVerified = true
 	return s.Users.UpdateUser(ctx, u)
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, *AuthTokens, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string, meta ClientMeta) (*models.User, *AuthTokens, error) {
 	email = utils.NormalizeEmail(email)
 	password = strings.TrimSpace(password)
+	if err := s.Limiter.Check(ctx, "login", email, meta.IP); err != nil {
+		return nil, nil, err
+	}
 	u, err := s.Users.GetByEmail(ctx, email)
 	if err != nil {
+		if err := s.Limiter.Fail(ctx, "login", email, meta.IP); err != nil {
+			return nil, nil, err
+		}
 		return nil, nil, errors.New("invalid_credentials")
 	}
 	ok, err := s.Hasher.Verify(u.Password, password)
 	if err != nil || !ok {
+		if err := s.Limiter.Fail(ctx, "login", email, meta.IP); err != nil {
+			return nil, nil, err
+		}
 		return nil, nil, errors.New("invalid_credentials")
 	}
+	if err := s.Limiter.Succeed(ctx, "login", email); err != nil {
+		return nil, nil, err
+	}
+	if !u.IsActive {
+		if u.DeletionScheduledAt != nil {
+			return u, nil, errors.New("account_pending_deletion")
+		}
+		return nil, nil, errors.New("account_disabled")
+	}
 	if !u.IsVerified {
 		return nil, nil, errors.New("email_not_verified")
 	}
-	if s.Email2FAEnabled && u.Is2FAEnabled {
-		return nil, nil, errors.New("2fa_required")
+	if len(s.TwoFactorMethods(u)) > 0 {
+		return u, nil, errors.New("2fa_required")
 	}
-	access, err := s.Tokens.IssueAccess(u)
+	tokens, err := s.issueTokens(ctx, u, "", meta)
 	if err != nil {
 		return nil, nil, err
 	}
-	refresh, err := s.Tokens.IssueRefresh(u)
-	if err != nil {
-		return nil, nil, err
-	}
-	return u, &AuthTokens{Access: access, Refresh: refresh}, nil
+	return u, tokens, nil
 }
 
 func (s *AuthService) ResendVerificationEmail(ctx context.Context, email string) error {
@@ -227,7 +296,7 @@ func (s *AuthService) Request2FACode(ctx context.Context, email string) error {
 	if !u.IsVerified {
 		return errors.New("email_not_verified")
 	}
-	if !u.Is2FAEnabled {
+	if !u.Email2FAEnabled {
 		return errors.New("2fa_not_enabled")
 	}
 	if err := s.Codes.DeleteByUserAndPurpose(ctx, u.ID, "login_2fa"); err != nil {
@@ -242,42 +311,215 @@ func (s *AuthService) Request2FACode(ctx context.Context, email string) error {
 	return nil
 }
 
-func (s *AuthService) VerifyLogin2FA(ctx context.Context, email, code string) (*models.User, *AuthTokens, error) {
-	email = utils.NormalizeEmail(email)
-	code = strings.TrimSpace(code)
+func (s *AuthService) VerifyLogin2FA(ctx context.Context, in Verify2FAInput, meta ClientMeta) (*models.User, *AuthTokens, error) {
+	email := utils.NormalizeEmail(in.Email)
+	code := strings.TrimSpace(in.Code)
+	if err := s.Limiter.Check(ctx, "2fa", email, meta.IP); err != nil {
+		return nil, nil, err
+	}
 	u, err := s.Users.GetByEmail(ctx, email)
 	if err != nil {
+		if err := s.Limiter.Fail(ctx, "2fa", email, meta.IP); err != nil {
+			return nil, nil, err
+		}
 		return nil, nil, errors.New("invalid_credentials")
 	}
-	if !u.Is2FAEnabled {
+	if !u.Is2FAEnabled() {
 		return nil, nil, errors.New("2fa_not_enabled")
 	}
-	v, err := s.Codes.GetValid(ctx, u.ID, "login_2fa", code)
-	if err != nil {
-		return nil, nil, errors.New("invalid_2fa_code")
+	if err := s.verifySecondFactor(ctx, u, in.Method, in.Challenge, code); err != nil {
+		switch err.Error() {
+		case "invalid_2fa_code", "invalid_challenge":
+			if err := s.Limiter.Fail(ctx, "2fa", email, meta.IP); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, err
 	}
-	if err := s.Codes.Consume(ctx, v.ID); err != nil {
+	if err := s.Limiter.Succeed(ctx, "2fa", email); err != nil {
 		return nil, nil, err
 	}
 	if s.Tokens == nil {
 		return u, &AuthTokens{}, nil
 	}
-	access, err := s.Tokens.IssueAccess(u)
+	tokens, err := s.issueTokens(ctx, u, "", meta)
 	if err != nil {
 		return nil, nil, err
 	}
-	refresh, err := s.Tokens.IssueRefresh(u)
+	return u, tokens, nil
+}
+
+func (s *AuthService) verifySecondFactor(ctx context.Context, u *models.User, method, challenge, code string) error {
+	if method == "" {
+		method = "email"
+		if !u.Email2FAEnabled {
+			method = "totp"
+		}
+	}
+	switch method {
+	case "email":
+		if !u.Email2FAEnabled {
+			return errors.New("2fa_method_not_enabled")
+		}
+		v, err := s.checkCode(ctx, u.ID, "login_2fa", code)
+		if err != nil {
+			if err.Error() == "invalid_code" {
+				return errors.New("invalid_2fa_code")
+			}
+			return err
+		}
+		return s.Codes.Consume(ctx, v.ID)
+	case "totp":
+		if !u.TOTPEnabled {
+			return errors.New("2fa_method_not_enabled")
+		}
+		sub, err := s.Tokens.ParseMFAChallenge(challenge)
+		if err != nil || sub != u.ID {
+			return errors.New("invalid_challenge")
+		}
+		return s.checkTOTP(ctx, u, code)
+	case "recovery":
+		sub, err := s.Tokens.ParseMFAChallenge(challenge)
+		if err != nil || sub != u.ID {
+			return errors.New("invalid_challenge")
+		}
+		return s.useRecoveryCode(ctx, u.ID, code)
+	default:
+		return errors.New("invalid_2fa_method")
+	}
+}
+
+// checkCode matches code against the user's active code for purpose. Every
+// miss counts against that code, which is burned after maxCodeAttempts.
+func (s *AuthService) checkCode(ctx context.Context, userID, purpose, code string) (*models.VerificationCode, error) {
+	v, err := s.Codes.GetActive(ctx, userID, purpose)
 	if err != nil {
-		return nil, nil, err
+		return nil, errors.New("invalid_code")
+	}
+	if v.Attempts >= maxCodeAttempts {
+		return nil, errors.New("too_many_code_attempts")
+	}
+	if subtle.ConstantTimeCompare([]byte(v.Code), []byte(code)) == 1 {
+		return v, nil
+	}
+	attempts, err := s.Codes.IncrementAttempts(ctx, v.ID)
+	if err != nil {
+		return nil, err
 	}
-	return u, &AuthTokens{Access: access, Refresh: refresh}, nil
+	if attempts >= maxCodeAttempts {
+		if err := s.Codes.Consume(ctx, v.ID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("too_many_code_attempts")
+	}
+	return nil, errors.New("invalid_code")
 }
 
-func (s *AuthService) Toggle2FA(ctx context.Context, userID string, enable bool) error {
+func (s *AuthService) Toggle2FA(ctx context.Context, userID string, enable bool) (*models.User, []string, error) {
 	u, err := s.Users.GetByID(ctx, userID)
 	if err != nil {
-		return errors.New("not_found")
+		return nil, nil, errors.New("not_found")
 	}
-	u.Is2FAEnabled = enable
-	return s.Users.UpdateUser(ctx, u)
+	u.Email2FAEnabled = enable
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return nil, nil, err
+	}
+	if !u.Is2FAEnabled() {
+		return u, nil, s.RecoveryCodes.DeleteByUser(ctx, u.ID)
+	}
+	codes, err := s.ensureRecoveryCodes(ctx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, codes, nil
+}
+
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta ClientMeta) (*models.User, *AuthTokens, error) {
+	claims, err := s.Tokens.ParseRefresh(refreshToken)
+	if err != nil {
+		return nil, nil, errors.New("invalid_refresh")
+	}
+	stored, err := s.RefreshTokens.GetByHash(ctx, auth.HashToken(refreshToken))
+	if err != nil || stored.ID != claims.ID || stored.UserID != claims.UserID {
+		return nil, nil, errors.New("invalid_refresh")
+	}
+	if stored.RevokedAt != nil || !stored.ExpiresAt.After(s.Clock.Now()) {
+		return nil, nil, errors.New("invalid_refresh")
+	}
+	if stored.UsedAt != nil {
+		if err := s.revokeSession(ctx, stored.UserID, stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("refresh_reused")
+	}
+	marked, err := s.RefreshTokens.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !marked {
+		if err := s.revokeSession(ctx, stored.UserID, stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("refresh_reused")
+	}
+	session, err := s.Sessions.GetByID(ctx, stored.FamilyID)
+	if err != nil || session.RevokedAt != nil {
+		_ = s.RefreshTokens.RevokeFamily(ctx, stored.FamilyID)
+		return nil, nil, errors.New("invalid_refresh")
+	}
+	u, err := s.Users.GetByID(ctx, stored.UserID)
+	if err != nil || !u.IsActive {
+		_ = s.revokeSession(ctx, stored.UserID, stored.FamilyID)
+		return nil, nil, errors.New("invalid_refresh")
+	}
+	tokens, err := s.issueTokens(ctx, u, session.ID, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, tokens, nil
+}
+
+func (s *AuthService) issueTokens(ctx context.Context, u *models.User, sessionID string, meta ClientMeta) (*AuthTokens, error) {
+	if !u.IsActive {
+		return nil, errors.New("account_disabled")
+	}
+	isNewSession := sessionID == ""
+	if isNewSession {
+		sessionID = cuid.New()
+	}
+	access, err := s.Tokens.IssueAccess(u, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	tokenID := cuid.New()
+	refresh, expiresAt, err := s.Tokens.IssueRefresh(u, tokenID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if isNewSession {
+		session := &models.Session{
+			ID:         sessionID,
+			UserID:     u.ID,
+			UserAgent:  meta.UserAgent,
+			IP:         meta.IP,
+			ExpiresAt:  expiresAt,
+			LastUsedAt: s.Clock.Now(),
+		}
+		if err := s.Sessions.Create(ctx, session); err != nil {
+			return nil, err
+		}
+	} else if err := s.Sessions.Touch(ctx, sessionID, meta.UserAgent, meta.IP, expiresAt); err != nil {
+		return nil, err
+	}
+	rt := &models.RefreshToken{
+		ID:        tokenID,
+		UserID:    u.ID,
+		FamilyID:  sessionID,
+		TokenHash: auth.HashToken(refresh),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.RefreshTokens.Create(ctx, rt); err != nil {
+		return nil, err
+	}
+	return &AuthTokens{Access: access, Refresh: refresh}, nil
 }
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/auth"
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DataExportDir = "exports"
+	DataExportTTL = 7 * 24 * time.Hour
+
+	exportPageSize = 500
+)
+
+// DataExportService builds the takeout archives queued through
+// DataExportRepository. Archives live outside the public uploads directory
+// and are only served through the download endpoints.
+type DataExportService struct {
+	Exports       repository.DataExportRepository
+	Users         repository.UserRepository
+	Posts         repository.PostRepository
+	Comments      repository.CommentRepository
+	Likes         repository.LikeRepository
+	Follows       repository.FollowRepository
+	Stories       repository.StoryRepository
+	Reposts       repository.RepostRepository
+	Bookmarks     repository.BookmarkRepository
+	Skills        repository.SkillRepository
+	Notifications repository.NotificationRepository
+	Chat          repository.ChatRepository
+	Mailer        EmailSender
+	BaseURL       string
+}
+
+func NewDataExportService(m repository.Models, mailer EmailSender, baseURL string) *DataExportService {
+	return &DataExportService{
+		Exports:       m.DataExports,
+		Users:         m.Users,
+		Posts:         m.Posts,
+		Comments:      m.Comments,
+		Likes:         m.Likes,
+		Follows:       m.Follows,
+		Stories:       m.Stories,
+		Reposts:       m.Reposts,
+		Bookmarks:     m.Bookmarks,
+		Skills:        m.Skills,
+		Notifications: m.Notifications,
+		Chat:          m.Chat,
+		Mailer:        mailer,
+		BaseURL:       strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *DataExportService) ProcessPending(ctx context.Context) error {
+	if err := s.Exports.RequeueStale(ctx, time.Now().Add(-time.Hour)); err != nil {
+		return fmt.Errorf("failed to requeue stale exports: %w", err)
+	}
+	for {
+		export, err := s.Exports.ClaimNext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to claim export: %w", err)
+		}
+		if export == nil {
+			return nil
+		}
+		if err := s.build(ctx, export); err != nil {
+			fmt.Printf("Warning: export %s failed: %v\n", export.ID, err)
+			if err := s.Exports.MarkFailed(ctx, export.ID, err.Error()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *DataExportService) CleanupExpired(ctx context.Context) error {
+	exports, err := s.Exports.ListExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list expired exports: %w", err)
+	}
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("Warning: failed to remove file %s: %v\n", export.FilePath, err)
+			}
+		}
+		if err := s.Exports.MarkExpired(ctx, export.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DataExportService) build(ctx context.Context, export *models.DataExport) error {
+	user, err := s.Users.GetByID(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(DataExportDir, user.ID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, export.ID+".zip")
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	err = s.writeArchive(ctx, zw, user)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	token := utils.GenerateAlphanumeric(48)
+	expiresAt := time.Now().Add(DataExportTTL)
+	if err := s.Exports.MarkReady(ctx, export.ID, path, info.Size(), auth.HashToken(token), expiresAt); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	_ = s.Notifications.Create(ctx, &models.Notification{
+		UserID:   user.ID,
+		ActorID:  user.ID,
+		Type:     models.NotificationTypeDataExport,
+		TargetID: &export.ID,
+	})
+	link := fmt.Sprintf("%s/api/v1/exports/%s/download?token=%s", s.BaseURL, export.ID, token)
+	_ = s.Mailer.Send(user.Email, "Архив с вашими данными готов", "Скачать архив: "+link+"\nСсылка действует до "+expiresAt.Format("02.01.2006 15:04")+".")
+	return nil
+}
+
+type exportManifest struct {
+	UserID      string         `json:"user_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       map[string]int `json:"files"`
+	Media       []exportMedia  `json:"media"`
+}
+
+type exportMedia struct {
+	URL     string `json:"url"`
+	Path    string `json:"path,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+type exportProfile struct {
+	ID              string    `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	FirstName       string    `json:"first_name"`
+	LastName        string    `json:"last_name"`
+	Bio             string    `json:"bio"`
+	AvatarURL       string    `json:"avatar_url"`
+	CoverURL        string    `json:"cover_url"`
+	Role            string    `json:"role"`
+	IsVerified      bool      `json:"is_verified"`
+	Email2FAEnabled bool      `json:"email_2fa_enabled"`
+	TOTPEnabled     bool      `json:"totp_enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type exportPost struct {
+	ID            string     `json:"id"`
+	Content       string     `json:"content"`
+	ImageURL      string     `json:"image_url,omitempty"`
+	Audience      string     `json:"audience"`
+	QuotedPostID  *string    `json:"quoted_post_id,omitempty"`
+	LikesCount    int        `json:"likes_count"`
+	CommentsCount int        `json:"comments_count"`
+	RepostsCount  int        `json:"reposts_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	EditedAt      *time.Time `json:"edited_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+type exportRevision struct {
+	PostID      string    `json:"post_id"`
+	Number      int       `json:"number"`
+	Content     string    `json:"content"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	ReplacedAt  time.Time `json:"replaced_at"`
+}
+
+type exportComment struct {
+	ID        string     `json:"id"`
+	PostID    string     `json:"post_id"`
+	Message   string     `json:"message"`
+	ImageURL  string     `json:"image_url,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type exportRepost struct {
+	PostID    string    `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportCollection struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportBookmark struct {
+	PostID       string    `json:"post_id"`
+	CollectionID *string   `json:"collection_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type exportBookmarks struct {
+	Collections []exportCollection `json:"collections"`
+	Bookmarks   []exportBookmark   `json:"bookmarks"`
+}
+
+type exportLike struct {
+	PostID    *string   `json:"post_id,omitempty"`
+	StoryID   *string   `json:"story_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportAccount struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type exportFollows struct {
+	Followers    []exportAccount `json:"followers"`
+	Following    []exportAccount `json:"following"`
+	CloseFriends []exportAccount `json:"close_friends"`
+}
+
+type exportSkill struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportStory struct {
+	ID         string     `json:"id"`
+	MediaURL   string     `json:"media_url"`
+	MediaType  string     `json:"media_type"`
+	LikesCount int        `json:"likes_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+type exportNotification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	ActorID   string    `json:"actor_id"`
+	TargetID  *string   `json:"target_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportConversation struct {
+	ID           string          `json:"id"`
+	Participants []string        `json:"participants"`
+	Messages     []exportMessage `json:"messages"`
+}
+
+type exportMessage struct {
+	ID        string    `json:"id"`
+	SenderID  string    `json:"sender_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *DataExportService) writeArchive(ctx context.Context, zw *zip.Writer, user *models.User) error {
+	manifest := exportManifest{UserID: user.ID, GeneratedAt: time.Now().UTC(), Files: map[string]int{}}
+	var media []string
+
+	profile := exportProfile{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Bio:             user.Bio,
+		AvatarURL:       user.AvatarURL,
+		CoverURL:        user.CoverURL,
+		Role:            string(user.Role),
+		IsVerified:      user.IsVerified,
+		Email2FAEnabled: user.Email2FAEnabled,
+		TOTPEnabled:     user.TOTPEnabled,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	if err := writeJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+	manifest.Files["profile.json"] = 1
+
+	posts, err := s.Posts.GetPostsForExport(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	postItems := make([]exportPost, 0, len(posts))
+	for _, p := range posts {
+		postItems = append(postItems, exportPost{
+			p.ID, p.Content, p.ImageURL, string(p.Audience), p.QuotedPostID,
+			p.LikesCount, p.CommentsCount, p.RepostsCount,
+			p.CreatedAt, p.UpdatedAt, p.EditedAt, deletedAt(p.DeletedAt),
+		})
+		media = append(media, p.ImageURL)
+		for _, m := range p.Media {
+			media = append(media, m.URL)
+		}
+	}
+	if err := writeJSON(zw, "posts.json", postItems); err != nil {
+		return err
+	}
+	manifest.Files["posts.json"] = len(postItems)
+
+	revisions, err := s.Posts.GetRevisionsForExport(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	revisionItems := make([]exportRevision, 0, len(revisions))
+	for _, r := range revisions {
+		revisionItems = append(revisionItems, exportRevision{r.PostID, r.Number, r.Content, r.ImageURL, r.PublishedAt, r.CreatedAt})
+		media = append(media, r.ImageURL)
+	}
+	if err := writeJSON(zw, "post_revisions.json", revisionItems); err != nil {
+		return err
+	}
+	manifest.Files["post_revisions.json"] = len(revisionItems)
+
+	comments, err := s.Comments.GetCommentsForExport(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	commentItems := make([]exportComment, 0, len(comments))
+	for _, c := range comments {
+		commentItems = append(commentItems, exportComment{c.ID, c.PostID, c.Message, c.ImageURL, c.CreatedAt, deletedAt(c.DeletedAt)})
+		media = append(media, c.ImageURL)
+	}
+	if err := writeJSON(zw, "comments.json", commentItems); err != nil {
+		return err
+	}
+	manifest.Files["comments.json"] = len(commentItems)
+
+	likes, err := s.Likes.GetLikesByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	likeItems := make([]exportLike, 0, len(likes))
+	for _, l := range likes {
+		likeItems = append(likeItems, exportLike{l.PostID, l.StoryID, l.CreatedAt})
+	}
+	if err := writeJSON(zw, "likes.json", likeItems); err != nil {
+		return err
+	}
+	manifest.Files["likes.json"] = len(likeItems)
+
+	reposts, err := s.Reposts.GetRepostsByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	repostItems := make([]exportRepost, 0, len(reposts))
+	for _, r := range reposts {
+		repostItems = append(repostItems, exportRepost{r.PostID, r.CreatedAt})
+	}
+	if err := writeJSON(zw, "reposts.json", repostItems); err != nil {
+		return err
+	}
+	manifest.Files["reposts.json"] = len(repostItems)
+
+	collections, bookmarks, err := s.Bookmarks.GetBookmarksForExport(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	bookmarkItems := exportBookmarks{
+		Collections: make([]exportCollection, 0, len(collections)),
+		Bookmarks:   make([]exportBookmark, 0, len(bookmarks)),
+	}
+	for _, c := range collections {
+		bookmarkItems.Collections = append(bookmarkItems.Collections, exportCollection{c.ID, c.Name, c.CreatedAt})
+	}
+	for _, b := range bookmarks {
+		bookmarkItems.Bookmarks = append(bookmarkItems.Bookmarks, exportBookmark{b.PostID, b.CollectionID, b.CreatedAt})
+	}
+	if err := writeJSON(zw, "bookmarks.json", bookmarkItems); err != nil {
+		return err
+	}
+	manifest.Files["bookmarks.json"] = len(bookmarkItems.Bookmarks)
+
+	follows := exportFollows{}
+	if follows.Followers, err = s.collectAccounts(ctx, user.ID, s.Follows.GetFollowers); err != nil {
+		return err
+	}
+	if follows.Following, err = s.collectAccounts(ctx, user.ID, s.Follows.GetFollowing); err != nil {
+		return err
+	}
+	if follows.CloseFriends, err = s.collectAccounts(ctx, user.ID, s.Follows.GetCloseFriends); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "follows.json", follows); err != nil {
+		return err
+	}
+	manifest.Files["follows.json"] = len(follows.Followers) + len(follows.Following)
+
+	skills, err := s.Skills.GetAllSkills(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	skillItems := make([]exportSkill, 0, len(skills))
+	for _, sk := range skills {
+		skillItems = append(skillItems, exportSkill{sk.Name, sk.CreatedAt})
+	}
+	if err := writeJSON(zw, "skills.json", skillItems); err != nil {
+		return err
+	}
+	manifest.Files["skills.json"] = len(skillItems)
+
+	stories, err := s.Stories.GetStoriesForExport(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	storyItems := make([]exportStory, 0, len(stories))
+	for _, st := range stories {
+		storyItems = append(storyItems, exportStory{st.ID, st.MediaURL, st.MediaType, st.LikesCount, st.CreatedAt, deletedAt(st.DeletedAt)})
+		media = append(media, st.MediaURL)
+	}
+	if err := writeJSON(zw, "stories.json", storyItems); err != nil {
+		return err
+	}
+	manifest.Files["stories.json"] = len(storyItems)
+
+	notificationItems := []exportNotification{}
+	for offset := 0; ; offset += exportPageSize {
+		page, err := s.Notifications.GetByUserID(ctx, user.ID, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, n := range page {
+			notificationItems = append(notificationItems, exportNotification{n.ID, string(n.Type), n.ActorID, n.TargetID, n.Read, n.CreatedAt})
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+	if err := writeJSON(zw, "notifications.json", notificationItems); err != nil {
+		return err
+	}
+	manifest.Files["notifications.json"] = len(notificationItems)
+
+	conversations, messageCount, err := s.collectConversations(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "messages.json", conversations); err != nil {
+		return err
+	}
+	manifest.Files["messages.json"] = messageCount
+
+	if manifest.Media, err = writeMedia(zw, user.ID, media); err != nil {
+		return err
+	}
+	return writeJSON(zw, "manifest.json", manifest)
+}
+
+func (s *DataExportService) collectAccounts(ctx context.Context, userID string, list func(context.Context, string, int, int) ([]models.User, error)) ([]exportAccount, error) {
+	accounts := []exportAccount{}
+	for offset := 0; ; offset += exportPageSize {
+		page, err := list(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range page {
+			accounts = append(accounts, exportAccount{u.ID, u.Username})
+		}
+		if len(page) < exportPageSize {
+			return accounts, nil
+		}
+	}
+}
+
+func (s *DataExportService) collectConversations(ctx context.Context, userID string) ([]exportConversation, int, error) {
+	conversations := []exportConversation{}
+	total := 0
+	for offset := 0; ; offset += exportPageSize {
+		page, err := s.Chat.ListUserConversations(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, conv := range page {
+			participants, err := s.Chat.ListParticipantIDs(ctx, conv.ID)
+			if err != nil {
+				return nil, 0, err
+			}
+			item := exportConversation{ID: conv.ID, Participants: participants, Messages: []exportMessage{}}
+			for msgOffset := 0; ; msgOffset += exportPageSize {
+				msgs, err := s.Chat.ListMessages(ctx, conv.ID, exportPageSize, msgOffset)
+				if err != nil {
+					return nil, 0, err
+				}
+				for _, m := range msgs {
+					item.Messages = append(item.Messages, exportMessage{m.ID, m.SenderID, m.Body, m.CreatedAt})
+				}
+				if len(msgs) < exportPageSize {
+					break
+				}
+			}
+			total += len(item.Messages)
+			conversations = append(conversations, item)
+		}
+		if len(page) < exportPageSize {
+			return conversations, total, nil
+		}
+	}
+}
+
+// deletedAt reports when a trashed item was deleted, or nil for live ones.
+func deletedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	return &d.Time
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeMedia copies the user's uploaded files under media/, keeping their
+// path below /uploads. The avatar and cover directories are included whole
+// so every resized variant ends up in the archive.
+func writeMedia(zw *zip.Writer, userID string, urls []string) ([]exportMedia, error) {
+	for _, dir := range []string{"avatars", "covers"} {
+		root := filepath.Join("uploads", dir, userID)
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				urls = append(urls, "/"+filepath.ToSlash(path))
+			}
+			return nil
+		})
+	}
+
+	seen := map[string]bool{}
+	items := []exportMedia{}
+	for _, url := range urls {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		if utils.IsRemoteURL(url) || !strings.HasPrefix(url, "/uploads/") || strings.Contains(url, "..") {
+			items = append(items, exportMedia{URL: url})
+			continue
+		}
+		name := "media/" + strings.TrimPrefix(url, "/uploads/")
+		ok, err := copyToZip(zw, strings.TrimPrefix(url, "/"), name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			items = append(items, exportMedia{URL: url, Missing: true})
+			continue
+		}
+		items = append(items, exportMedia{URL: url, Path: name})
+	}
+	return items, nil
+}
+
+func copyToZip(zw *zip.Writer, src, name string) (bool, error) {
+	f, err := os.Open(src)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// Images and videos are already compressed.
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return false, err
+	}
+	return true, nil
+}
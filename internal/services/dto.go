@@ -12,3 +12,15 @@ type AuthTokens struct {
 	Access  string `json:"token"`
 	Refresh string `json:"-"`
 }
+
+type ClientMeta struct {
+	UserAgent string
+	IP        string
+}
+
+type Verify2FAInput struct {
+	Email     string
+	Code      string
+	Method    string
+	Challenge string
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/utils"
+)
+
+const emailChangePurpose = "email_change"
+
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID, newEmail, password string, meta ClientMeta) error {
+	newEmail = utils.NormalizeEmail(newEmail)
+	if !utils.IsValidEmail(newEmail) {
+		return errors.New("invalid_email")
+	}
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("not_found")
+	}
+	if err := s.Limiter.Check(ctx, emailChangePurpose, u.Email, meta.IP); err != nil {
+		return err
+	}
+	ok, err := s.Hasher.Verify(u.Password, password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := s.Limiter.Fail(ctx, emailChangePurpose, u.Email, meta.IP); err != nil {
+			return err
+		}
+		return errors.New("invalid_credentials")
+	}
+	if newEmail == u.Email {
+		return errors.New("same_email")
+	}
+	if existing, _ := s.Users.GetByEmail(ctx, newEmail); existing != nil {
+		return errors.New("email_in_use")
+	}
+
+	if err := s.Codes.DeleteByUserAndPurpose(ctx, u.ID, emailChangePurpose); err != nil {
+		return err
+	}
+	code := utils.GenerateDigits(6)
+	v := &models.VerificationCode{UserID: u.ID, Purpose: emailChangePurpose, Code: code, Target: newEmail, ExpiresAt: s.Clock.Now().Add(30 * time.Minute)}
+	if err := s.Codes.Create(ctx, v); err != nil {
+		return err
+	}
+	_ = s.Mailer.Send(newEmail, "Подтверждение новой почты", "Ваш код для смены почты: "+code)
+	_ = s.Mailer.Send(u.Email, "Запрос на смену почты", "Для вашего аккаунта запрошена смена почты на "+newEmail+". Если это были не вы, смените пароль.")
+	return nil
+}
+
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, userID, code string, meta ClientMeta) (*models.User, error) {
+	code = strings.TrimSpace(code)
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("not_found")
+	}
+	if err := s.Limiter.Check(ctx, emailChangePurpose, u.Email, meta.IP); err != nil {
+		return nil, err
+	}
+	v, err := s.checkCode(ctx, u.ID, emailChangePurpose, code)
+	if err != nil {
+		if err.Error() == "invalid_code" {
+			if err := s.Limiter.Fail(ctx, emailChangePurpose, u.Email, meta.IP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	if err := s.Limiter.Succeed(ctx, emailChangePurpose, u.Email); err != nil {
+		return nil, err
+	}
+
+	oldEmail := u.Email
+	if err := s.Users.ChangeEmail(ctx, u.ID, v.ID, v.Target); err != nil {
+		return nil, err
+	}
+	u.Email = v.Target
+	_ = s.Mailer.Send(oldEmail, "Почта изменена", "Почта вашего аккаунта изменена на "+u.Email+". Если это были не вы, срочно свяжитесь с поддержкой.")
+	return u, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"modern-social-media/internal/repository"
+)
+
+// RankingSignals are the inputs a Scorer sees for one candidate post.
+type RankingSignals struct {
+	Likes          int
+	Comments       int
+	Age            time.Duration
+	AuthorFollowed bool
+	// SocialProof counts accounts the viewer follows that follow the author.
+	SocialProof int
+	// AuthorLikes counts the viewer's recent likes on the author's posts.
+	AuthorLikes int
+}
+
+type ScoreBreakdown struct {
+	Engagement float64 `json:"engagement"`
+	Recency    float64 `json:"recency"`
+	Affinity   float64 `json:"affinity"`
+	Total      float64 `json:"total"`
+}
+
+// Scorer turns signals into a score. Candidate generation and paging don't
+// depend on the implementation, so ranking experiments only need a new
+// Scorer.
+type Scorer interface {
+	Score(RankingSignals) ScoreBreakdown
+}
+
+type RankingWeights struct {
+	Like        float64
+	Comment     float64
+	Follow      float64
+	SocialProof float64
+	AuthorLike  float64
+	HalfLife    time.Duration
+}
+
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{
+		Like:        1,
+		Comment:     2,
+		Follow:      1.5,
+		SocialProof: 0.3,
+		AuthorLike:  0.5,
+		HalfLife:    12 * time.Hour,
+	}
+}
+
+// EngagementScorer multiplies three factors: log-scaled engagement, an
+// exponential recency decay with the configured half-life, and an affinity
+// boost for authors the viewer follows or interacts with.
+type EngagementScorer struct {
+	Weights RankingWeights
+}
+
+func (s EngagementScorer) Score(sig RankingSignals) ScoreBreakdown {
+	w := s.Weights
+	b := ScoreBreakdown{
+		Engagement: 1 + math.Log1p(w.Like*float64(sig.Likes)+w.Comment*float64(sig.Comments)),
+		Recency:    1,
+		Affinity:   1 + w.SocialProof*math.Log1p(float64(sig.SocialProof)) + w.AuthorLike*math.Log1p(float64(sig.AuthorLikes)),
+	}
+	if w.HalfLife > 0 {
+		b.Recency = math.Pow(0.5, sig.Age.Hours()/w.HalfLife.Hours())
+	}
+	if sig.AuthorFollowed {
+		b.Affinity += w.Follow
+	}
+	b.Total = b.Engagement * b.Recency * b.Affinity
+	return b
+}
+
+type RankedPost struct {
+	repository.RankingCandidate
+	Score ScoreBreakdown
+}
+
+type FeedRanker struct {
+	Posts  repository.PostRepository
+	Scorer Scorer
+	// CandidateWindow and CandidateLimit bound how many posts get scored.
+	CandidateWindow time.Duration
+	CandidateLimit  int
+	// Debug lets every viewer request score breakdowns.
+	Debug bool
+}
+
+// RankCursor pins a ranking to the moment its first page was built. AsOf fixes
+// the candidate window and the recency decay, so every page is scored
+// against the same clock and newer posts wait for the next refresh; Score,
+// CreatedAt and ID identify the last post served. Engagement still moves
+// between requests, so a post can drift across the boundary, but paging no
+// longer repeats or skips whole stretches the way an offset into a re-sorted
+// list does.
+type RankCursor struct {
+	AsOf      time.Time `json:"as_of"`
+	Score     float64   `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// CursorAfter returns the cursor for the page following p.
+func (p RankedPost) CursorAfter(asOf time.Time) RankCursor {
+	return RankCursor{AsOf: asOf, Score: p.Score.Total, CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+// before reports whether p was already served, i.e. ranks at or ahead of the
+// cursor position: higher score
+// first, then newer, then by ID so the order is total.
+func (c RankCursor) before(p RankedPost) bool {
+	if p.Score.Total != c.Score {
+		return p.Score.Total > c.Score
+	}
+	if !p.CreatedAt.Equal(c.CreatedAt) {
+		return p.CreatedAt.After(c.CreatedAt)
+	}
+	return p.ID >= c.ID
+}
+
+// Rank scores the candidates as of after.AsOf and returns up to limit posts
+// ranked below the cursor. A cursor without an ID starts from the top.
+func (r *FeedRanker) Rank(ctx context.Context, viewerID string, after RankCursor, limit int) ([]RankedPost, error) {
+	now := after.AsOf
+	candidates, err := r.Posts.RankingCandidates(ctx, viewerID, now.Add(-r.CandidateWindow), now, r.CandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]RankedPost, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = RankedPost{
+			RankingCandidate: c,
+			Score: r.Scorer.Score(RankingSignals{
+				Likes:          c.LikesCount,
+				Comments:       c.CommentsCount,
+				Age:            now.Sub(c.CreatedAt),
+				AuthorFollowed: c.AuthorFollowed,
+				SocialProof:    c.SocialProof,
+				AuthorLikes:    c.AuthorLikes,
+			}),
+		}
+	}
+	// Candidates arrive newest first by (created_at, id), so a stable sort
+	// breaks ties the same way RankCursor does.
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score.Total > ranked[j].Score.Total
+	})
+
+	page := make([]RankedPost, 0, limit)
+	for _, p := range ranked {
+		if len(page) == limit {
+			break
+		}
+		if after.ID != "" && after.before(p) {
+			continue
+		}
+		page = append(page, p)
+	}
+	return page, nil
+}
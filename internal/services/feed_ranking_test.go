@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"modern-social-media/internal/models"
+)
+
+func rankedAt(id string, score float64, createdAt time.Time) RankedPost {
+	p := RankedPost{Score: ScoreBreakdown{Total: score}}
+	p.RankingCandidate.FeedPost.Post = models.Post{ID: id, CreatedAt: createdAt}
+	return p
+}
+
+func TestRankCursorBefore(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cursor := rankedAt("m", 2, t0).CursorAfter(t0.Add(time.Hour))
+	tests := []struct {
+		name string
+		post RankedPost
+		want bool
+	}{
+		{"higher score", rankedAt("a", 3, t0.Add(-time.Hour)), true},
+		{"lower score", rankedAt("z", 1, t0.Add(time.Hour)), false},
+		{"same score, newer", rankedAt("a", 2, t0.Add(time.Second)), true},
+		{"same score, older", rankedAt("z", 2, t0.Add(-time.Second)), false},
+		{"same score and time, higher id", rankedAt("n", 2, t0), true},
+		{"same score and time, lower id", rankedAt("l", 2, t0), false},
+		{"the cursor post itself", rankedAt("m", 2, t0), true},
+	}
+	for _, tt := range tests {
+		if got := cursor.before(tt.post); got != tt.want {
+			t.Errorf("%s: before = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
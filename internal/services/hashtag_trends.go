@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"modern-social-media/internal/repository"
+)
+
+// HashtagTrends periodically rebuilds the trending tags snapshot so
+// GET /tags/trending only reads a small precomputed table.
+type HashtagTrends struct {
+	Repo    repository.HashtagRepository
+	Params  repository.TrendingParams
+	Refresh time.Duration
+}
+
+func (s *HashtagTrends) RefreshTrending(ctx context.Context) error {
+	if _, err := s.Repo.RefreshTrending(ctx, time.Now(), s.Params); err != nil {
+		return fmt.Errorf("failed to refresh trending hashtags: %w", err)
+	}
+	return nil
+}
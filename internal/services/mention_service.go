@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type MentionService struct {
+	Users         repository.UserRepository
+	Posts         repository.PostRepository
+	Mentions      repository.MentionRepository
+	Notifications repository.NotificationRepository
+	Chat          repository.ChatRepository
+}
+
+func NewMentionService(m repository.Models) *MentionService {
+	return &MentionService{
+		Users:         m.Users,
+		Posts:         m.Posts,
+		Mentions:      m.Mentions,
+		Notifications: m.Notifications,
+		Chat:          m.Chat,
+	}
+}
+
+// SyncPost, SyncComment and SyncMessage resolve the @mentions in text, store
+// them for the source and notify users mentioned there for the first time.
+// The returned mentions are in text order.
+func (s *MentionService) SyncPost(ctx context.Context, postID, authorID, text string) ([]models.Mention, error) {
+	viewers, err := s.postViewers(ctx, postID, text)
+	if err != nil {
+		return nil, err
+	}
+	return s.sync(ctx, models.MentionSourcePost, postID, authorID, text, viewers)
+}
+
+// SyncComment resolves mentions in a comment on postID.
+func (s *MentionService) SyncComment(ctx context.Context, commentID, postID, authorID, text string) ([]models.Mention, error) {
+	viewers, err := s.postViewers(ctx, postID, text)
+	if err != nil {
+		return nil, err
+	}
+	return s.sync(ctx, models.MentionSourceComment, commentID, authorID, text, viewers)
+}
+
+// postViewers limits mentions in a post, or in comments on it, to users who
+// can see the post. Anyone else would only get a notification for something
+// that 404s, which still gives away that it exists and who wrote it.
+func (s *MentionService) postViewers(ctx context.Context, postID, text string) (map[string]bool, error) {
+	matches := utils.ExtractMentions(text)
+	usernames := make([]string, len(matches))
+	for i, m := range matches {
+		usernames[i] = m.Username
+	}
+	return s.Posts.ViewersByUsername(ctx, postID, usernames)
+}
+
+// SyncMessage only resolves participants of the conversation: mentioning
+// someone who can't read the message would tell them nothing useful and
+// leak that the conversation exists.
+func (s *MentionService) SyncMessage(ctx context.Context, msg *models.Message) ([]models.Mention, error) {
+	ids, err := s.Chat.ListParticipantIDs(ctx, msg.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	participants := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		participants[id] = true
+	}
+	return s.sync(ctx, models.MentionSourceMessage, msg.ID, msg.SenderID, msg.Body, participants)
+}
+
+func (s *MentionService) sync(ctx context.Context, sourceType, sourceID, authorID, text string, allowed map[string]bool) ([]models.Mention, error) {
+	resolved := map[string]*models.User{}
+	mentions := []models.Mention{}
+	for _, m := range utils.ExtractMentions(text) {
+		user, seen := resolved[m.Username]
+		if !seen {
+			var err error
+			user, err = s.Users.GetByUsername(ctx, m.Username)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if user != nil && (!user.IsActive || user.ID == authorID || (allowed != nil && !allowed[user.ID])) {
+				user = nil
+			}
+			resolved[m.Username] = user
+		}
+		if user == nil {
+			continue
+		}
+		mentions = append(mentions, models.Mention{
+			AuthorID: authorID,
+			UserID:   user.ID,
+			Username: user.Username,
+			Offset:   m.Offset,
+			Length:   m.Length,
+		})
+	}
+
+	added, err := s.Mentions.Replace(ctx, sourceType, sourceID, mentions)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range added {
+		target := sourceID
+		_ = s.Notifications.Create(ctx, &models.Notification{
+			UserID:     userID,
+			ActorID:    authorID,
+			Type:       models.NotificationTypeMention,
+			TargetID:   &target,
+			TargetType: sourceType,
+		})
+	}
+	return mentions, nil
+}
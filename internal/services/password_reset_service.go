@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/utils"
+)
+
+// ForgotPassword emails a reset code if email belongs to an active account.
+// The lookup, the code and the email all happen in the background, so the
+// caller answers the same way, just as fast, whether or not the address is
+// registered.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) {
+	email = utils.NormalizeEmail(email)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			fmt.Printf("Warning: password reset email failed: %v\n", err)
+		}
+	}()
+}
+
+func (s *AuthService) sendPasswordReset(ctx context.Context, email string) error {
+	u, err := s.Users.GetByEmail(ctx, email)
+	if err != nil || !u.IsActive {
+		return nil
+	}
+	if err := s.Codes.DeleteByUserAndPurpose(ctx, u.ID, "password_reset"); err != nil {
+		return err
+	}
+	code := utils.GenerateDigits(6)
+	v := &models.VerificationCode{UserID: u.ID, Purpose: "password_reset", Code: code, ExpiresAt: s.Clock.Now().Add(15 * time.Minute)}
+	if err := s.Codes.Create(ctx, v); err != nil {
+		return err
+	}
+	return s.Mailer.Send(u.Email, "Сброс пароля", "Ваш код для сброса пароля: "+code)
+}
+
+func (s *AuthService) ResetPassword(ctx context.Context, email, code, newPassword string, meta ClientMeta) error {
+	email = utils.NormalizeEmail(email)
+	code = strings.TrimSpace(code)
+	newPassword = strings.TrimSpace(newPassword)
+	if len(newPassword) < 8 {
+		return errors.New("weak_password")
+	}
+	if err := s.Limiter.Check(ctx, "password_reset", email, meta.IP); err != nil {
+		return err
+	}
+	u, err := s.Users.GetByEmail(ctx, email)
+	if err != nil {
+		if err := s.Limiter.Fail(ctx, "password_reset", email, meta.IP); err != nil {
+			return err
+		}
+		return errors.New("invalid_code")
+	}
+	v, err := s.checkCode(ctx, u.ID, "password_reset", code)
+	if err != nil {
+		if err.Error() == "invalid_code" {
+			if err := s.Limiter.Fail(ctx, "password_reset", email, meta.IP); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	if err := s.Limiter.Succeed(ctx, "password_reset", email); err != nil {
+		return err
+	}
+	hash, err := s.Hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.Codes.Consume(ctx, v.ID); err != nil {
+		return err
+	}
+	u.Password = hash
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return err
+	}
+	if err := s.Sessions.RevokeAllForUser(ctx, u.ID, ""); err != nil {
+		return err
+	}
+	return s.RefreshTokens.RevokeAllForUser(ctx, u.ID, "")
+}
+
+// ChangePassword sets a new password for a signed-in user who knows the
+// current one. Wrong guesses count against the same limiter as login so a
+// stolen access token can't be used to test passwords. Every other session
+// is signed out.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string, meta ClientMeta) (*models.User, error) {
+	newPassword = strings.TrimSpace(newPassword)
+	if len(newPassword) < 8 {
+		return nil, errors.New("weak_password")
+	}
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("not_found")
+	}
+	if err := s.Limiter.Check(ctx, "password_change", u.Email, meta.IP); err != nil {
+		return nil, err
+	}
+	ok, err := s.Hasher.Verify(u.Password, strings.TrimSpace(currentPassword))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.Limiter.Fail(ctx, "password_change", u.Email, meta.IP); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid_credentials")
+	}
+	if err := s.Limiter.Succeed(ctx, "password_change", u.Email); err != nil {
+		return nil, err
+	}
+	hash, err := s.Hasher.Hash(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = hash
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	if err := s.Sessions.RevokeAllForUser(ctx, u.ID, sessionID); err != nil {
+		return nil, err
+	}
+	if err := s.RefreshTokens.RevokeAllForUser(ctx, u.ID, sessionID); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/utils"
+)
+
+const recoveryCodeCount = 10
+
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, password string) ([]string, error) {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("not_found")
+	}
+	ok, err := s.Hasher.Verify(u.Password, strings.TrimSpace(password))
+	if err != nil || !ok {
+		return nil, errors.New("invalid_credentials")
+	}
+	if !u.Is2FAEnabled() {
+		return nil, errors.New("2fa_not_enabled")
+	}
+	return s.generateRecoveryCodes(ctx, u.ID)
+}
+
+func (s *AuthService) RecoveryCodesRemaining(ctx context.Context, userID string) (int64, error) {
+	return s.RecoveryCodes.CountUnused(ctx, userID)
+}
+
+func (s *AuthService) ensureRecoveryCodes(ctx context.Context, u *models.User) ([]string, error) {
+	remaining, err := s.RecoveryCodes.CountUnused(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if remaining > 0 {
+		return nil, nil
+	}
+	return s.generateRecoveryCodes(ctx, u.ID)
+}
+
+func (s *AuthService) generateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := utils.GenerateAlphanumeric(10)
+		hash, err := s.Hasher.Hash(raw)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hash
+	}
+	if err := s.RecoveryCodes.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *AuthService) useRecoveryCode(ctx context.Context, userID, code string) error {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return errors.New("invalid_2fa_code")
+	}
+	unused, err := s.RecoveryCodes.ListUnused(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, rc := range unused {
+		ok, err := s.Hasher.Verify(rc.CodeHash, code)
+		if err != nil || !ok {
+			continue
+		}
+		marked, err := s.RecoveryCodes.MarkUsed(ctx, rc.ID)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return errors.New("invalid_2fa_code")
+		}
+		return nil
+	}
+	return errors.New("invalid_2fa_code")
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
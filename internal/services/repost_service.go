@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+
+	"modern-social-media/internal/models"
+	"modern-social-media/internal/repository"
+)
+
+type RepostService struct {
+	Reposts       repository.RepostRepository
+	Notifications repository.NotificationRepository
+}
+
+func NewRepostService(m repository.Models) *RepostService {
+	return &RepostService{
+		Reposts:       m.Reposts,
+		Notifications: m.Notifications,
+	}
+}
+
+// Repost shares postID and notifies its author the first time userID does
+// so. Reposting your own post is allowed but never notifies.
+func (s *RepostService) Repost(ctx context.Context, userID, postID string) (*models.Post, error) {
+	post, created, err := s.Reposts.Repost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	if created && post.UserID != userID {
+		target := post.ID
+		_ = s.Notifications.Create(ctx, &models.Notification{
+			UserID:     post.UserID,
+			ActorID:    userID,
+			Type:       models.NotificationTypeRepost,
+			TargetID:   &target,
+			TargetType: models.MentionSourcePost,
+		})
+	}
+	return post, nil
+}
+
+// Unrepost takes a repost back, along with the notification it caused.
+func (s *RepostService) Unrepost(ctx context.Context, userID, postID string) (*models.Post, error) {
+	post, removed, err := s.Reposts.Unrepost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	if removed && post.UserID != userID {
+		_ = s.Notifications.DeleteByTarget(ctx, post.UserID, userID, models.NotificationTypeRepost, post.ID)
+	}
+	return post, nil
+}
+
+// NotifyQuote tells the author of the quoted post about a new quote.
+func (s *RepostService) NotifyQuote(ctx context.Context, quote, original *models.Post) {
+	if original.UserID == quote.UserID {
+		return
+	}
+	target := quote.ID
+	_ = s.Notifications.Create(ctx, &models.Notification{
+		UserID:     original.UserID,
+		ActorID:    quote.UserID,
+		Type:       models.NotificationTypeQuote,
+		TargetID:   &target,
+		TargetType: models.MentionSourcePost,
+	})
+}
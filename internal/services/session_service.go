@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"modern-social-media/internal/models"
+)
+
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	return s.Sessions.ListActiveByUser(ctx, userID)
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	revoked, err := s.Sessions.Revoke(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("not_found")
+	}
+	return s.RefreshTokens.RevokeFamily(ctx, sessionID)
+}
+
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	if err := s.Sessions.RevokeAllForUser(ctx, userID, currentSessionID); err != nil {
+		return err
+	}
+	return s.RefreshTokens.RevokeAllForUser(ctx, userID, currentSessionID)
+}
+
+func (s *AuthService) revokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := s.Sessions.Revoke(ctx, sessionID, userID); err != nil {
+		return err
+	}
+	return s.RefreshTokens.RevokeFamily(ctx, sessionID)
+}
@@ -11,24 +11,35 @@ import (
 )
 
 type TokenService interface {
-	IssueAccess(u *models.User) (string, error)
-	IssueRefresh(u *models.User) (string, error)
-	ParseRefresh(token string) (string, error)
+	IssueAccess(u *models.User, sessionID string) (string, error)
+	IssueRefresh(u *models.User, tokenID, familyID string) (string, time.Time, error)
+	ParseRefresh(token string) (*auth.RefreshClaims, error)
+	IssueMFAChallenge(u *models.User) (string, error)
+	ParseMFAChallenge(token string) (string, error)
 }
 
+const mfaChallengeTTL = 5 * time.Minute
+
 type JWTTokenService struct {
-	Secret     []byte
+	Keys       *auth.KeyManager
 	AccessTTL  time.Duration
 	RefreshTTL time.Duration
 }
 
-func (s *JWTTokenService) IssueAccess(u *models.User) (string, error) {
+func (s *JWTTokenService) IssueAccess(u *models.User, sessionID string) (string, error) {
 	now := time.Now()
+	perms := make([]string, 0, len(u.Role.Permissions()))
+	for _, p := range u.Role.Permissions() {
+		perms = append(perms, string(p))
+	}
 	claims := auth.AccessClaims{
-		UserID:   u.ID,
-		Email:    u.Email,
-		Username: u.Username,
-		Type:     "access",
+		UserID:      u.ID,
+		SessionID:   sessionID,
+		Email:       u.Email,
+		Username:    u.Username,
+		Role:        string(u.Role),
+		Permissions: perms,
+		Type:        "access",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   u.ID,
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -36,46 +47,69 @@ func (s *JWTTokenService) IssueAccess(u *models.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.Secret)
+	return s.Keys.Sign(claims)
 }
 
-func (s *JWTTokenService) IssueRefresh(u *models.User) (string, error) {
+func (s *JWTTokenService) IssueRefresh(u *models.User, tokenID, familyID string) (string, time.Time, error) {
 	now := time.Now()
+	expiresAt := now.Add(s.RefreshTTL)
 	claims := auth.RefreshClaims{
-		UserID: u.ID,
-		Type:   "refresh",
+		UserID:   u.ID,
+		FamilyID: familyID,
+		Type:     "refresh",
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			Subject:   u.ID,
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.RefreshTTL)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.Secret)
+	signed, err := s.Keys.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
-func (s *JWTTokenService) ParseRefresh(tokenStr string) (string, error) {
+func (s *JWTTokenService) ParseRefresh(tokenStr string) (*auth.RefreshClaims, error) {
 	var claims auth.RefreshClaims
 
-	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return s.Secret, nil
-	})
+	if err := s.Keys.Parse(tokenStr, &claims); err != nil {
+		return nil, errors.New("invalid_refresh")
+	}
 
-	if err != nil {
-		return "", errors.New("invalid_refresh")
+	if err := claims.Validate(); err != nil {
+		return nil, errors.New("invalid_refresh")
 	}
 
-	if !token.Valid {
-		return "", errors.New("invalid_refresh")
+	return &claims, nil
+}
+
+func (s *JWTTokenService) IssueMFAChallenge(u *models.User) (string, error) {
+	now := time.Now()
+	claims := auth.MFAClaims{
+		UserID: u.ID,
+		Type:   "mfa",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+		},
+	}
+
+	return s.Keys.Sign(claims)
+}
+
+func (s *JWTTokenService) ParseMFAChallenge(tokenStr string) (string, error) {
+	var claims auth.MFAClaims
+
+	if err := s.Keys.Parse(tokenStr, &claims); err != nil {
+		return "", errors.New("invalid_challenge")
 	}
 
 	if err := claims.Validate(); err != nil {
-		return "", errors.New("invalid_refresh")
+		return "", errors.New("invalid_challenge")
 	}
 
 	return claims.UserID, nil
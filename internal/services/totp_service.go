@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"modern-social-media/internal/auth"
+	"modern-social-media/internal/models"
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+func (s *AuthService) TwoFactorMethods(u *models.User) []string {
+	methods := []string{}
+	if s.Email2FAEnabled && u.Email2FAEnabled {
+		methods = append(methods, "email")
+	}
+	if u.TOTPEnabled {
+		methods = append(methods, "totp")
+	}
+	return methods
+}
+
+func (s *AuthService) Issue2FAChallenge(u *models.User) (string, error) {
+	return s.Tokens.IssueMFAChallenge(u)
+}
+
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("not_found")
+	}
+	if u.TOTPEnabled {
+		return nil, errors.New("totp_already_enabled")
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	u.TOTPSecret = secret
+	u.TOTPLastCounter = 0
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: auth.TOTPURI(s.TOTPIssuer, u.Email, secret)}, nil
+}
+
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("not_found")
+	}
+	if u.TOTPEnabled {
+		return nil, errors.New("totp_already_enabled")
+	}
+	if u.TOTPSecret == "" {
+		return nil, errors.New("totp_not_enrolled")
+	}
+	if err := s.checkTOTP(ctx, u, code); err != nil {
+		return nil, err
+	}
+	u.TOTPEnabled = true
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return s.ensureRecoveryCodes(ctx, u)
+}
+
+func (s *AuthService) DisableTOTP(ctx context.Context, userID, code string) error {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("not_found")
+	}
+	if !u.TOTPEnabled {
+		return errors.New("totp_not_enabled")
+	}
+	if err := s.checkTOTP(ctx, u, code); err != nil {
+		return err
+	}
+	u.TOTPEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPLastCounter = 0
+	if err := s.Users.UpdateUser(ctx, u); err != nil {
+		return err
+	}
+	if !u.Is2FAEnabled() {
+		return s.RecoveryCodes.DeleteByUser(ctx, u.ID)
+	}
+	return nil
+}
+
+func (s *AuthService) checkTOTP(ctx context.Context, u *models.User, code string) error {
+	counter, ok := auth.ValidateTOTP(u.TOTPSecret, code, s.Clock.Now())
+	if !ok || counter <= u.TOTPLastCounter {
+		return errors.New("invalid_2fa_code")
+	}
+	advanced, err := s.Users.AdvanceTOTPCounter(ctx, u.ID, counter)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return errors.New("invalid_2fa_code")
+	}
+	u.TOTPLastCounter = counter
+	return nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"modern-social-media/internal/repository"
+	"modern-social-media/internal/utils"
+	"time"
+)
+
+type TrashPurgeService struct {
+	Repo repository.TrashRepository
+}
+
+func NewTrashPurgeService(repo repository.TrashRepository) *TrashPurgeService {
+	return &TrashPurgeService{Repo: repo}
+}
+
+func (s *TrashPurgeService) PurgeExpired(ctx context.Context) error {
+	orphaned, err := s.Repo.PurgeExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
+	for _, url := range orphaned {
+		utils.RemoveUpload(url)
+	}
+	return nil
+}
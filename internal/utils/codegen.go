@@ -14,3 +14,13 @@ func GenerateDigits(length int) string {
 	}
 	return string(out)
 }
+
+func GenerateAlphanumeric(length int) string {
+	alphabet := "abcdefghjkmnpqrstuvwxyz23456789"
+	out := make([]byte, length)
+	for i := 0; i < length; i++ {
+		nBig, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		out[i] = alphabet[nBig.Int64()]
+	}
+	return string(out)
+}
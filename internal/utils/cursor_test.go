@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+type testCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []testCursor{
+		{},
+		{CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 890, time.UTC), ID: "ckx1abc"},
+		{CreatedAt: time.Date(1999, 12, 31, 23, 59, 59, 0, time.FixedZone("x", 3600)), ID: "id/with+chars=="},
+	}
+	for _, want := range tests {
+		token := EncodeCursor(want)
+		var got testCursor
+		if err := DecodeCursor(token, &got); err != nil {
+			t.Fatalf("DecodeCursor(%q): %v", token, err)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+			t.Errorf("round trip = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "***"},
+		{"padded base64", "eyJpZCI6IngifQ=="},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{"wrong shape", EncodeCursor([]int{1, 2})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c testCursor
+			err := DecodeCursor(tt.token, &c)
+			if err == nil || err.Error() != "invalid_cursor" {
+				t.Fatalf("DecodeCursor(%q) = %v, want invalid_cursor", tt.token, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxHashtagLength   = 64
+	MaxHashtagsPerPost = 20
+)
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// ExtractHashtags returns the distinct #tags in text, lowercased and in order
+// of first appearance. A tag has to start at a word boundary ("a#b" is not a
+// tag), contain at least one letter and fit in MaxHashtagLength runes.
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '#' || isHashtagRune(prev) || prev == '#' || prev == '&' {
+			prev = r
+			i += size
+			continue
+		}
+
+		end := i + size
+		for end < len(text) {
+			next, n := utf8.DecodeRuneInString(text[end:])
+			if !isHashtagRune(next) {
+				break
+			}
+			end += n
+		}
+		if tag, ok := NormalizeHashtag(text[i+size : end]); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+			if len(tags) == MaxHashtagsPerPost {
+				break
+			}
+		}
+		prev, _ = utf8.DecodeLastRuneInString(text[:end])
+		i = end
+	}
+	return tags
+}
+
+// NormalizeHashtag lowercases a tag, dropping a leading '#', and reports
+// whether the result is a valid tag name.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	n := utf8.RuneCountInString(tag)
+	if n == 0 || n > MaxHashtagLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return tag, hasLetter
+}
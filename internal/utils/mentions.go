@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxUsernameLength   = 50
+	MaxMentionedPerText = 20
+)
+
+// MentionMatch is one "@username" occurrence. Offset and Length count
+// Unicode code points and cover the '@'.
+type MentionMatch struct {
+	Username string
+	Offset   int
+	Length   int
+}
+
+func isUsernameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// ExtractMentions returns every "@username" in text in order. Like hashtags,
+// a mention has to start at a word boundary, so e-mail addresses don't
+// count. Trailing dots and dashes are treated as punctuation. At most
+// MaxMentionedPerText distinct usernames are returned.
+func ExtractMentions(text string) []MentionMatch {
+	var matches []MentionMatch
+	distinct := map[string]bool{}
+	prev := ' '
+	pos := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || isUsernameRune(prev) || prev == '@' {
+			prev = r
+			i += size
+			pos++
+			continue
+		}
+
+		end := i + size
+		n := 0
+		for end < len(text) {
+			next, w := utf8.DecodeRuneInString(text[end:])
+			if !isUsernameRune(next) {
+				break
+			}
+			end += w
+			n++
+		}
+		for end > i+size && (text[end-1] == '.' || text[end-1] == '-') {
+			end--
+			n--
+		}
+
+		username := text[i+size : end]
+		if n > 0 && n <= maxUsernameLength {
+			if !distinct[username] && len(distinct) == MaxMentionedPerText {
+				break
+			}
+			distinct[username] = true
+			matches = append(matches, MentionMatch{Username: username, Offset: pos, Length: n + 1})
+		}
+		prev, _ = utf8.DecodeLastRuneInString(text[:end])
+		pos += n + 1
+		i = end
+	}
+	return matches
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func IsRemoteURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// RemoveUpload deletes the file behind a local /uploads/ URL. Remote URLs
+// and anything that could escape the uploads directory are ignored.
+func RemoveUpload(url string) {
+	if url == "" || IsRemoteURL(url) || !strings.HasPrefix(url, "/uploads/") || strings.Contains(url, "..") {
+		return
+	}
+	if err := os.Remove(strings.TrimPrefix(url, "/")); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove file %s: %v\n", url, err)
+	}
+}
@@ -1,6 +1,10 @@
 package utils
 
-import netmail "net/mail"
+import (
+	netmail "net/mail"
+	"net/url"
+	"strings"
+)
 
 func IsValidEmail(value string) bool {
 	if value == "" {
@@ -9,3 +13,12 @@ func IsValidEmail(value string) bool {
 	_, err := netmail.ParseAddress(value)
 	return err == nil
 }
+
+// IsValidMediaURL accepts files served from /uploads or absolute http(s) URLs.
+func IsValidMediaURL(value string) bool {
+	if strings.HasPrefix(value, "/uploads/") {
+		return !strings.Contains(value, "..")
+	}
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}